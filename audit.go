@@ -0,0 +1,515 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+)
+
+// graphQLClient is implemented by the go-gh GraphQL client.
+type graphQLClient interface {
+	Do(query string, variables map[string]interface{}, response interface{}) error
+}
+
+// advisory is one vulnerable version range of an action. The JSON form is also
+// the format of the offline file accepted by audit --advisories.
+type advisory struct {
+	GHSAID                 string `json:"ghsaId"`
+	Summary                string `json:"summary"`
+	Severity               string `json:"severity"`
+	Permalink              string `json:"permalink,omitempty"`
+	Package                string `json:"package"`
+	VulnerableVersionRange string `json:"vulnerableVersionRange"`
+	FirstPatchedVersion    string `json:"firstPatchedVersion,omitempty"`
+}
+
+// advisorySource lists the advisories for an owner/repo package.
+type advisorySource interface {
+	Advisories(pkg string) ([]advisory, error)
+}
+
+const securityVulnerabilitiesQuery = `query($package: String!, $cursor: String) {
+  securityVulnerabilities(first: 100, ecosystem: ACTIONS, package: $package, after: $cursor) {
+    nodes {
+      advisory { ghsaId summary severity permalink }
+      package { name }
+      vulnerableVersionRange
+      firstPatchedVersion { identifier }
+    }
+    pageInfo { hasNextPage endCursor }
+  }
+}`
+
+// graphQLAdvisories queries the GitHub Advisory Database.
+type graphQLAdvisories struct {
+	client graphQLClient
+}
+
+func (s graphQLAdvisories) Advisories(pkg string) ([]advisory, error) {
+	var result []advisory
+	var cursor interface{}
+	for {
+		var response struct {
+			SecurityVulnerabilities struct {
+				Nodes []struct {
+					Advisory struct {
+						GHSAID    string `json:"ghsaId"`
+						Summary   string `json:"summary"`
+						Severity  string `json:"severity"`
+						Permalink string `json:"permalink"`
+					} `json:"advisory"`
+					Package struct {
+						Name string `json:"name"`
+					} `json:"package"`
+					VulnerableVersionRange string `json:"vulnerableVersionRange"`
+					FirstPatchedVersion    *struct {
+						Identifier string `json:"identifier"`
+					} `json:"firstPatchedVersion"`
+				} `json:"nodes"`
+				PageInfo struct {
+					HasNextPage bool   `json:"hasNextPage"`
+					EndCursor   string `json:"endCursor"`
+				} `json:"pageInfo"`
+			} `json:"securityVulnerabilities"`
+		}
+		variables := map[string]interface{}{"package": pkg, "cursor": cursor}
+		if err := s.client.Do(securityVulnerabilitiesQuery, variables, &response); err != nil {
+			return nil, err
+		}
+		for _, node := range response.SecurityVulnerabilities.Nodes {
+			entry := advisory{
+				GHSAID:                 node.Advisory.GHSAID,
+				Summary:                node.Advisory.Summary,
+				Severity:               node.Advisory.Severity,
+				Permalink:              node.Advisory.Permalink,
+				Package:                node.Package.Name,
+				VulnerableVersionRange: node.VulnerableVersionRange,
+			}
+			if node.FirstPatchedVersion != nil {
+				entry.FirstPatchedVersion = node.FirstPatchedVersion.Identifier
+			}
+			result = append(result, entry)
+		}
+		if !response.SecurityVulnerabilities.PageInfo.HasNextPage {
+			break
+		}
+		cursor = response.SecurityVulnerabilities.PageInfo.EndCursor
+	}
+	return result, nil
+}
+
+// fileAdvisories serves advisories from a local JSON array for offline use.
+type fileAdvisories []advisory
+
+func loadAdvisoryFile(path string) (fileAdvisories, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var advisories fileAdvisories
+	if err := json.Unmarshal(content, &advisories); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return advisories, nil
+}
+
+func (s fileAdvisories) Advisories(pkg string) ([]advisory, error) {
+	var result []advisory
+	for _, entry := range s {
+		if strings.EqualFold(entry.Package, pkg) {
+			result = append(result, entry)
+		}
+	}
+	return result, nil
+}
+
+// inVersionRange reports whether version satisfies an advisory range such as
+// "< 45.0.8" or ">= 1.0.0, < 1.2.3". Every comma-separated constraint must
+// hold.
+func inVersionRange(version semver, expr string) (bool, error) {
+	for _, constraint := range strings.Split(expr, ",") {
+		constraint = strings.TrimSpace(constraint)
+		if constraint == "" {
+			continue
+		}
+		op := constraint[:len(constraint)-len(strings.TrimLeft(constraint, "<>=!"))]
+		bound, ok := parseSemver(strings.TrimSpace(constraint[len(op):]))
+		if !ok {
+			return false, fmt.Errorf("unsupported version range %q", expr)
+		}
+		cmp := version.Compare(bound)
+		var holds bool
+		switch op {
+		case "<":
+			holds = cmp < 0
+		case "<=":
+			holds = cmp <= 0
+		case ">":
+			holds = cmp > 0
+		case ">=":
+			holds = cmp >= 0
+		case "=", "==", "":
+			holds = cmp == 0
+		case "!=":
+			holds = cmp != 0
+		default:
+			return false, fmt.Errorf("unsupported version range %q", expr)
+		}
+		if !holds {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+type auditFinding struct {
+	Action    string   `json:"action"`
+	Version   string   `json:"version"`
+	Advisory  string   `json:"advisory"`
+	Summary   string   `json:"summary"`
+	Severity  string   `json:"severity"`
+	Range     string   `json:"vulnerableVersionRange"`
+	Patched   string   `json:"firstPatchedVersion,omitempty"`
+	Permalink string   `json:"permalink,omitempty"`
+	Locations []string `json:"locations"`
+	usages    []*ActionUsage
+}
+
+func cmdAudit(args []string) int {
+	client, err := api.DefaultRESTClient()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create GitHub client: %v\n", err)
+		return 1
+	}
+
+	files, err := loadWorkflowFiles()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load workflow files: %v\n", err)
+		return 1
+	}
+
+	if len(allUsages(files)) == 0 {
+		fmt.Println("No workflow or composite action usages found.")
+		return 0
+	}
+
+	var gql graphQLClient
+	if graphQL, err := api.DefaultGraphQLClient(); err == nil {
+		gql = graphQL
+	}
+
+	exit := runAudit(os.Stdout, client, gql, files, args)
+	return exit
+}
+
+// runAudit reports usages whose resolved tag falls inside a vulnerable range
+// and exits with status 1 while any remain.
+func runAudit(w io.Writer, client restClient, gql graphQLClient, files []*WorkflowFile, args []string) int {
+	fs := flag.NewFlagSet("audit", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	format := fs.String("format", "table", "output format (table, json)")
+	advisoryFile := fs.String("advisories", "", "read advisories from a local JSON file instead of the GitHub Advisory Database")
+	fix := fs.Bool("fix", false, "upgrade vulnerable usages to the first patched version")
+	styleFlag := commentStyleFlag(fs)
+
+	var publish publishOptions
+	publish.register(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+
+	if fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "audit does not accept positional arguments")
+		return 1
+	}
+
+	if *format != "table" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "unknown format %q (expected table or json)\n", *format)
+		return 1
+	}
+
+	if (publish.Commit || publish.CreatePR) && !*fix {
+		fmt.Fprintln(os.Stderr, "--commit and --create-pr require --fix")
+		return 1
+	}
+
+	var source advisorySource
+	if *advisoryFile != "" {
+		advisories, err := loadAdvisoryFile(*advisoryFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to load advisories: %v\n", err)
+			return 1
+		}
+		source = advisories
+	} else {
+		if gql == nil {
+			fmt.Fprintln(os.Stderr, "failed to create GitHub GraphQL client; use --advisories for offline audits")
+			return 1
+		}
+		source = graphQLAdvisories{client: gql}
+	}
+
+	style, err := effectiveCommentStyle(*styleFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if *fix {
+		if err := publish.validate(files); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load configuration: %v\n", err)
+		return 1
+	}
+
+	resolver := NewTagResolver(client)
+	findings, warnings := collectAuditFindings(resolver, source, files, cfg.TagPrefixes)
+
+	if *fix {
+		fixed, fixWarnings, err := fixAuditFindings(resolver, files, findings, cfg.TagPrefixes, style)
+		warnings = append(warnings, fixWarnings...)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if len(fixed) > 0 {
+			for _, line := range fixed {
+				fmt.Fprintln(w, line)
+			}
+			if err := publish.publish(client, files, "audit"); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 1
+			}
+			findings, _ = collectAuditFindings(resolver, source, files, cfg.TagPrefixes)
+		}
+	}
+
+	switch *format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(findings)
+	default:
+		err = writeAuditTable(w, findings)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write report: %v\n", err)
+		return 1
+	}
+
+	for _, warning := range warnings {
+		fmt.Fprintln(os.Stderr, warning)
+	}
+
+	if len(findings) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// auditVersion determines the exact tag a usage runs. A full SHA is
+// reverse-resolved to the tag pointing at the pinned commit, so a stale pin
+// under a floating comment such as v4 is audited as the release it really
+// runs; its comment is only used when no tag points at the commit. Floating
+// comments and refs are resolved to the newest matching release. Only tags
+// in the action's tag family, given by prefix, are considered.
+func auditVersion(resolver *TagResolver, usage *ActionUsage, prefix string) (string, error) {
+	if isFullCommitSHA(usage.Ref) {
+		tag, err := resolver.TagForCommit(usage.Spec.Owner, usage.Spec.Repo, usage.Ref)
+		if err != nil {
+			return "", err
+		}
+		if tagPrefix, _ := splitTagPrefix(tag); tag != "" && strings.EqualFold(tagPrefix, prefix) {
+			return tag, nil
+		}
+	}
+	version, _ := splitComment(usage.Comment)
+	if version == "" {
+		if isFullCommitSHA(usage.Ref) {
+			return "", nil
+		}
+		version = usage.Ref
+	} else {
+		version = withTagPrefix(version, prefix)
+	}
+	if kind, _ := classifyVersionSpec(version); kind == specExact {
+		return version, nil
+	}
+	tag, _, err := resolver.ResolveSpec(usage.Spec.Owner, usage.Spec.Repo, version)
+	return tag, err
+}
+
+func collectAuditFindings(resolver *TagResolver, source advisorySource, files []*WorkflowFile, prefixes map[string]string) ([]*auditFinding, []string) {
+	advisories := make(map[string][]advisory)
+	fetchErrors := make(map[string]bool)
+	findings := make(map[string]*auditFinding)
+	var order []string
+	var warnings []string
+
+	for _, usage := range allUsages(files) {
+		location := fmt.Sprintf("%s:%d", usage.File.Path, usage.LineNumber())
+		pkg := fmt.Sprintf("%s/%s", usage.Spec.Owner, usage.Spec.Repo)
+		key := usage.Spec.RepoKey()
+
+		list, ok := advisories[key]
+		if !ok && !fetchErrors[key] {
+			var err error
+			list, err = source.Advisories(pkg)
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("unable to fetch advisories for %s: %v", pkg, err))
+				fetchErrors[key] = true
+				continue
+			}
+			advisories[key] = list
+		}
+		if len(list) == 0 {
+			continue
+		}
+
+		prefix, err := resolver.TagPrefix(usage.Spec, prefixes)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s unable to determine the tag prefix of %s: %v", location, usage.Spec.FullPath(), err))
+			continue
+		}
+		tag, err := auditVersion(resolver, usage, prefix)
+		if err != nil || tag == "" {
+			warnings = append(warnings, fmt.Sprintf("%s unable to determine the version of %s to audit", location, pkg))
+			continue
+		}
+		version, ok := parseTagVersion(tag)
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("%s cannot audit non-semver version %s of %s", location, tag, pkg))
+			continue
+		}
+
+		for _, entry := range list {
+			vulnerable, err := inVersionRange(version, entry.VulnerableVersionRange)
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("%s %s: %v", pkg, entry.GHSAID, err))
+				continue
+			}
+			if !vulnerable {
+				continue
+			}
+			findingKey := strings.ToLower(fmt.Sprintf("%s|%s|%s", key, tag, entry.GHSAID))
+			finding, exists := findings[findingKey]
+			if !exists {
+				finding = &auditFinding{
+					Action:    pkg,
+					Version:   tag,
+					Advisory:  entry.GHSAID,
+					Summary:   entry.Summary,
+					Severity:  strings.ToLower(entry.Severity),
+					Range:     entry.VulnerableVersionRange,
+					Patched:   entry.FirstPatchedVersion,
+					Permalink: entry.Permalink,
+				}
+				findings[findingKey] = finding
+				order = append(order, findingKey)
+			}
+			finding.Locations = append(finding.Locations, location)
+			finding.usages = append(finding.usages, usage)
+		}
+	}
+
+	sort.Strings(order)
+	result := make([]*auditFinding, 0, len(order))
+	for _, key := range order {
+		result = append(result, findings[key])
+	}
+	return result, warnings
+}
+
+// fixAuditFindings re-pins each vulnerable usage to the highest first patched
+// version among the advisories affecting it, within its tag family, and saves
+// the changed files.
+func fixAuditFindings(resolver *TagResolver, files []*WorkflowFile, findings []*auditFinding, prefixes map[string]string, style commentStyle) ([]string, []string, error) {
+	targets := make(map[*ActionUsage]string)
+	var order []*ActionUsage
+	var warnings []string
+	for _, finding := range findings {
+		if finding.Patched == "" {
+			warnings = append(warnings, fmt.Sprintf("%s %s has no patched version", finding.Action, finding.Advisory))
+			continue
+		}
+		patched, ok := parseSemver(finding.Patched)
+		if !ok {
+			continue
+		}
+		for _, usage := range finding.usages {
+			current, seen := targets[usage]
+			if !seen {
+				order = append(order, usage)
+			}
+			if currentVersion, ok := parseSemver(current); !seen || !ok || patched.Compare(currentVersion) > 0 {
+				targets[usage] = finding.Patched
+			}
+		}
+	}
+
+	var fixed []string
+	for _, usage := range order {
+		prefix, err := resolver.TagPrefix(usage.Spec, prefixes)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s:%d unable to determine the tag prefix of %s: %v",
+				usage.File.Path, usage.LineNumber(), usage.Spec.FullPath(), err))
+			continue
+		}
+		tag, commit, err := resolver.ResolveSpec(usage.Spec.Owner, usage.Spec.Repo, withTagPrefix(ensureLeadingV(targets[usage]), prefix))
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s:%d unable to resolve patched version %s of %s: %v",
+				usage.File.Path, usage.LineNumber(), targets[usage], usage.Spec.FullPath(), err))
+			continue
+		}
+		usage.Set(commit, usage.CommentWithVersion(tag, "", style))
+		fixed = append(fixed, fmt.Sprintf("Upgraded %s at %s:%d to %s (%s).",
+			usage.Spec.FullPath(), usage.File.Path, usage.LineNumber(), tag, shortSHA(commit)))
+	}
+
+	for _, file := range files {
+		if err := file.Save(); err != nil {
+			return fixed, warnings, fmt.Errorf("failed to write %s: %w", file.Path, err)
+		}
+	}
+	return fixed, warnings, nil
+}
+
+func writeAuditTable(w io.Writer, findings []*auditFinding) error {
+	if len(findings) == 0 {
+		fmt.Fprintln(w, "No known vulnerabilities found.")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ACTION\tVERSION\tADVISORY\tSEVERITY\tPATCHED\tSUMMARY")
+	for _, finding := range findings {
+		patched := finding.Patched
+		if patched == "" {
+			patched = "none"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			finding.Action, finding.Version, finding.Advisory, finding.Severity, patched, finding.Summary)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	for _, finding := range findings {
+		fmt.Fprintf(w, "%s affects %s\n", finding.Advisory, strings.Join(finding.Locations, ", "))
+	}
+	return nil
+}
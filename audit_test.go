@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInVersionRange(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		version string
+		expr    string
+		want    bool
+	}{
+		{"v45.0.7", "< 45.0.8", true},
+		{"v45.0.8", "< 45.0.8", false},
+		{"v1.2.0", ">= 1.0.0, < 1.2.3", true},
+		{"v0.9.0", ">= 1.0.0, < 1.2.3", false},
+		{"v2.0.0", "= 2.0.0", true},
+		{"v2.0.1", "<= 2.0", false},
+	}
+	for _, tc := range cases {
+		version, _ := parseSemver(tc.version)
+		got, err := inVersionRange(version, tc.expr)
+		if err != nil || got != tc.want {
+			t.Fatalf("inVersionRange(%s, %q) = %v (%v), want %v", tc.version, tc.expr, got, err, tc.want)
+		}
+	}
+	if _, err := inVersionRange(semver{Major: 1}, "~> 1.0"); err == nil {
+		t.Fatal("expected unsupported range to fail")
+	}
+}
+
+type mockGraphQLClient struct {
+	responses []string
+	calls     int
+}
+
+func (m *mockGraphQLClient) Do(query string, variables map[string]interface{}, response interface{}) error {
+	body := m.responses[m.calls]
+	m.calls++
+	return json.Unmarshal([]byte(body), response)
+}
+
+func TestGraphQLAdvisories(t *testing.T) {
+	t.Parallel()
+	client := &mockGraphQLClient{responses: []string{
+		`{"securityVulnerabilities": {"nodes": [{"advisory": {"ghsaId": "GHSA-1"}, "package": {"name": "tj-actions/changed-files"}, "vulnerableVersionRange": "< 45.0.8", "firstPatchedVersion": {"identifier": "45.0.8"}}], "pageInfo": {"hasNextPage": true, "endCursor": "abc"}}}`,
+		`{"securityVulnerabilities": {"nodes": [{"advisory": {"ghsaId": "GHSA-2"}, "package": {"name": "tj-actions/changed-files"}, "vulnerableVersionRange": "<= 1.0.0", "firstPatchedVersion": null}], "pageInfo": {"hasNextPage": false}}}`,
+	}}
+
+	advisories, err := graphQLAdvisories{client: client}.Advisories("tj-actions/changed-files")
+	if err != nil {
+		t.Fatalf("Advisories error: %v", err)
+	}
+	if len(advisories) != 2 || advisories[0].FirstPatchedVersion != "45.0.8" || advisories[1].FirstPatchedVersion != "" {
+		t.Fatalf("unexpected advisories: %+v", advisories)
+	}
+}
+
+func TestRunAudit(t *testing.T) {
+	t.Parallel()
+	const vulnerableCommit = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	const patchedCommit = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+
+	advisoryPath := filepath.Join(t.TempDir(), "advisories.json")
+	advisories := `[{"ghsaId": "GHSA-mrrh-fwg8-r2c3", "summary": "changed-files leaks secrets", "severity": "HIGH",
+		"package": "tj-actions/changed-files", "vulnerableVersionRange": "< 45.0.8", "firstPatchedVersion": "45.0.8"}]`
+	if err := os.WriteFile(advisoryPath, []byte(advisories), 0o644); err != nil {
+		t.Fatalf("failed to write advisories: %v", err)
+	}
+
+	mock := newMockRESTClient(t).
+		withJSON("repos/tj-actions/changed-files/git/ref/tags/v45.0.8", map[string]interface{}{
+			"object": map[string]interface{}{"sha": patchedCommit, "type": "commit"},
+		}).
+		withJSON("repos/tj-actions/changed-files/tags?per_page=100&page=1", []map[string]interface{}{
+			{"name": "v45", "commit": map[string]interface{}{"sha": patchedCommit}},
+			{"name": "v45.0.8", "commit": map[string]interface{}{"sha": patchedCommit}},
+			{"name": "v45.0.7", "commit": map[string]interface{}{"sha": vulnerableCommit}},
+		})
+
+	wf := buildWorkflowFileFromLines(t,
+		"      - uses: tj-actions/changed-files@"+vulnerableCommit+" # v45.0.7",
+		"      - uses: actions/checkout@"+vulnerableCommit+" # v4.1.0",
+	)
+
+	var out bytes.Buffer
+	if exit := runAudit(&out, mock, nil, []*WorkflowFile{wf}, []string{"--advisories", advisoryPath}); exit != 1 {
+		t.Fatalf("expected audit to fail on a vulnerable usage, got %d", exit)
+	}
+	if !strings.Contains(out.String(), "GHSA-mrrh-fwg8-r2c3") || !strings.Contains(out.String(), "45.0.8") {
+		t.Fatalf("unexpected report:\n%s", out.String())
+	}
+
+	out.Reset()
+	if exit := runAudit(&out, mock, nil, []*WorkflowFile{wf}, []string{"--advisories", advisoryPath, "--fix"}); exit != 0 {
+		t.Fatalf("expected audit --fix to resolve the finding, got %d:\n%s", exit, out.String())
+	}
+	if want := "      - uses: tj-actions/changed-files@" + patchedCommit + " # v45.0.8"; wf.Lines[0] != want {
+		t.Fatalf("fixed line = %q, want %q", wf.Lines[0], want)
+	}
+	if !strings.Contains(out.String(), "No known vulnerabilities found.") {
+		t.Fatalf("unexpected report after fix:\n%s", out.String())
+	}
+
+	// A stale pin under a floating comment is audited as the release it
+	// actually runs, not the newest release the comment matches.
+	floating := buildWorkflowFile(t, "      - uses: tj-actions/changed-files@"+vulnerableCommit+" # v45")
+	out.Reset()
+	if exit := runAudit(&out, mock, nil, []*WorkflowFile{floating}, []string{"--advisories", advisoryPath, "--format", "json"}); exit != 1 {
+		t.Fatalf("expected a vulnerable SHA under a floating comment to be reported, got %d:\n%s", exit, out.String())
+	}
+	var findings []auditFinding
+	if err := json.Unmarshal(out.Bytes(), &findings); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	if len(findings) != 1 || findings[0].Version != "v45.0.7" {
+		t.Fatalf("expected the finding to name v45.0.7, got %+v", findings)
+	}
+}
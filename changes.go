@@ -0,0 +1,426 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+	"gopkg.in/yaml.v3"
+)
+
+// actionMetadata is the subset of an action.yml file needed to compare two
+// versions of an action.
+type actionMetadata struct {
+	Name    string                  `yaml:"name"`
+	Inputs  map[string]actionInput  `yaml:"inputs"`
+	Outputs map[string]actionOutput `yaml:"outputs"`
+	Runs    actionRuns              `yaml:"runs"`
+}
+
+type actionInput struct {
+	Description        string `yaml:"description"`
+	Required           string `yaml:"required"`
+	Default            string `yaml:"default"`
+	DeprecationMessage string `yaml:"deprecationMessage"`
+}
+
+func (i actionInput) IsRequired() bool {
+	return strings.EqualFold(strings.TrimSpace(i.Required), "true") && i.Default == ""
+}
+
+type actionOutput struct {
+	Description string `yaml:"description"`
+}
+
+type actionRuns struct {
+	Using string `yaml:"using"`
+}
+
+// fetchActionMetadata downloads and parses action.yml (or action.yaml) for the
+// action at the given ref.
+func fetchActionMetadata(client restClient, spec ActionSpec, ref string) (*actionMetadata, error) {
+	var lastErr error
+	for _, name := range []string{"action.yml", "action.yaml"} {
+		content, err := fetchFileContent(client, spec.Owner, spec.Repo, path.Join(spec.Path, name), ref)
+		if err != nil {
+			var httpErr *api.HTTPError
+			if errors.As(err, &httpErr) && httpErr.StatusCode == 404 {
+				lastErr = err
+				continue
+			}
+			return nil, err
+		}
+		var metadata actionMetadata
+		if err := yaml.Unmarshal(content, &metadata); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+		return &metadata, nil
+	}
+	return nil, fmt.Errorf("no action.yml found for %s at %s: %w", spec.FullPath(), ref, lastErr)
+}
+
+// fetchFileContent reads a file from a repository at the given ref via the
+// contents API.
+func fetchFileContent(client restClient, owner, repo, filePath, ref string) ([]byte, error) {
+	escaped := strings.ReplaceAll(url.PathEscape(filePath), "%2F", "/")
+	endpoint := fmt.Sprintf("repos/%s/%s/contents/%s?ref=%s", owner, repo, escaped, url.QueryEscape(ref))
+	var response struct {
+		Type     string `json:"type"`
+		Content  string `json:"content"`
+		Encoding string `json:"encoding"`
+	}
+	if err := client.Get(endpoint, &response); err != nil {
+		return nil, err
+	}
+	if response.Type != "" && response.Type != "file" {
+		return nil, fmt.Errorf("%s is a %s, not a file", filePath, response.Type)
+	}
+	if response.Encoding != "base64" {
+		return []byte(response.Content), nil
+	}
+	return base64.StdEncoding.DecodeString(response.Content)
+}
+
+type actionChanges struct {
+	Spec          ActionSpec
+	FromLabel     string
+	ToLabel       string
+	UsingBefore   string
+	UsingAfter    string
+	AddedInputs   []string
+	RemovedInputs []string
+	NowRequired   []string
+	Deprecated    []string
+	AddedOutputs  []string
+	RemovedOuts   []string
+	Warnings      []string
+}
+
+func (c *actionChanges) Empty() bool {
+	return c.UsingBefore == c.UsingAfter && len(c.AddedInputs) == 0 && len(c.RemovedInputs) == 0 &&
+		len(c.NowRequired) == 0 && len(c.Deprecated) == 0 && len(c.AddedOutputs) == 0 &&
+		len(c.RemovedOuts) == 0 && len(c.Warnings) == 0
+}
+
+// diffActionMetadata compares two versions of an action's metadata and
+// cross-references the inputs passed by the given usages.
+func diffActionMetadata(before, after *actionMetadata, toLabel string, usages []*ActionUsage, inputs *stepInputIndex) *actionChanges {
+	changes := &actionChanges{
+		ToLabel:     toLabel,
+		UsingBefore: before.Runs.Using,
+		UsingAfter:  after.Runs.Using,
+	}
+
+	for name, input := range after.Inputs {
+		previous, existed := before.Inputs[name]
+		if !existed {
+			changes.AddedInputs = append(changes.AddedInputs, name)
+		}
+		if input.IsRequired() && (!existed || !previous.IsRequired()) {
+			changes.NowRequired = append(changes.NowRequired, name)
+		}
+		if input.DeprecationMessage != "" && (!existed || previous.DeprecationMessage == "") {
+			changes.Deprecated = append(changes.Deprecated, name)
+		}
+	}
+	for name := range before.Inputs {
+		if _, ok := after.Inputs[name]; !ok {
+			changes.RemovedInputs = append(changes.RemovedInputs, name)
+		}
+	}
+	for name := range after.Outputs {
+		if _, ok := before.Outputs[name]; !ok {
+			changes.AddedOutputs = append(changes.AddedOutputs, name)
+		}
+	}
+	for name := range before.Outputs {
+		if _, ok := after.Outputs[name]; !ok {
+			changes.RemovedOuts = append(changes.RemovedOuts, name)
+		}
+	}
+
+	sort.Strings(changes.AddedInputs)
+	sort.Strings(changes.RemovedInputs)
+	sort.Strings(changes.NowRequired)
+	sort.Strings(changes.Deprecated)
+	sort.Strings(changes.AddedOutputs)
+	sort.Strings(changes.RemovedOuts)
+
+	for _, usage := range usages {
+		used := inputs.For(usage)
+		location := fmt.Sprintf("%s:%d", usage.File.Path, usage.LineNumber())
+		for _, name := range changes.RemovedInputs {
+			if line, ok := used[name]; ok {
+				changes.Warnings = append(changes.Warnings, fmt.Sprintf("input `%s` used in %s:%d was removed in %s",
+					name, usage.File.Path, line, toLabel))
+			}
+		}
+		for _, name := range changes.Deprecated {
+			if line, ok := used[name]; ok {
+				changes.Warnings = append(changes.Warnings, fmt.Sprintf("input `%s` used in %s:%d is deprecated in %s: %s",
+					name, usage.File.Path, line, toLabel, after.Inputs[name].DeprecationMessage))
+			}
+		}
+		for _, name := range changes.NowRequired {
+			if _, ok := used[name]; !ok {
+				changes.Warnings = append(changes.Warnings, fmt.Sprintf("input `%s` is required in %s but not set by %s",
+					name, toLabel, location))
+			}
+		}
+	}
+	return changes
+}
+
+// collectActionChanges diffs the metadata of every distinct (sub-path, ref)
+// referenced by the usages against the target commit. Fetch failures are
+// returned as errors alongside the changes that could be computed.
+func collectActionChanges(client restClient, usages []*ActionUsage, targetLabel, targetCommit string, inputs *stepInputIndex) ([]*actionChanges, []error) {
+	type group struct {
+		spec   ActionSpec
+		ref    string
+		label  string
+		usages []*ActionUsage
+	}
+	groups := make(map[string]*group)
+	var order []string
+	for _, usage := range usages {
+		if usage.Spec.IsReusableWorkflow() || strings.EqualFold(usage.Ref, targetCommit) {
+			continue
+		}
+		key := fmt.Sprintf("%s|%s", strings.ToLower(usage.Spec.FullPath()), strings.ToLower(usage.Ref))
+		g, ok := groups[key]
+		if !ok {
+			label, _ := splitComment(usage.Comment)
+			if label == "" {
+				label = usage.Ref
+			}
+			if isFullCommitSHA(label) {
+				label = shortSHA(label)
+			}
+			g = &group{spec: usage.Spec, ref: usage.Ref, label: label}
+			groups[key] = g
+			order = append(order, key)
+		}
+		g.usages = append(g.usages, usage)
+	}
+
+	var results []*actionChanges
+	var errs []error
+	targets := make(map[string]*actionMetadata)
+	for _, key := range order {
+		g := groups[key]
+		pathKey := strings.ToLower(g.spec.FullPath())
+		after, ok := targets[pathKey]
+		if !ok {
+			var err error
+			after, err = fetchActionMetadata(client, g.spec, targetCommit)
+			if err != nil {
+				errs = append(errs, err)
+			}
+			targets[pathKey] = after
+		}
+		if after == nil {
+			continue
+		}
+		before, err := fetchActionMetadata(client, g.spec, g.ref)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		changes := diffActionMetadata(before, after, targetLabel, g.usages, inputs)
+		changes.Spec = g.spec
+		changes.FromLabel = g.label
+		results = append(results, changes)
+	}
+	return results, errs
+}
+
+func writeActionChanges(w io.Writer, changes *actionChanges) {
+	fmt.Fprintf(w, "%s %s -> %s\n", changes.Spec.FullPath(), changes.FromLabel, changes.ToLabel)
+	if changes.Empty() {
+		fmt.Fprintln(w, "  no changes to inputs, outputs, or runtime")
+		return
+	}
+	if changes.UsingBefore != changes.UsingAfter {
+		fmt.Fprintf(w, "  runs.using changed: %s -> %s\n", changes.UsingBefore, changes.UsingAfter)
+	}
+	for _, name := range changes.RemovedInputs {
+		fmt.Fprintf(w, "  input `%s` removed\n", name)
+	}
+	for _, name := range changes.AddedInputs {
+		fmt.Fprintf(w, "  input `%s` added\n", name)
+	}
+	for _, name := range changes.NowRequired {
+		fmt.Fprintf(w, "  input `%s` is now required\n", name)
+	}
+	for _, name := range changes.Deprecated {
+		fmt.Fprintf(w, "  input `%s` deprecated\n", name)
+	}
+	for _, name := range changes.RemovedOuts {
+		fmt.Fprintf(w, "  output `%s` removed\n", name)
+	}
+	for _, name := range changes.AddedOutputs {
+		fmt.Fprintf(w, "  output `%s` added\n", name)
+	}
+}
+
+func writeActionChangeWarnings(w io.Writer, changes *actionChanges) {
+	for _, warning := range changes.Warnings {
+		fmt.Fprintf(w, "warning: %s\n", warning)
+	}
+}
+
+// stepInputIndex maps each `uses:` line to the `with:` keys passed alongside
+// it, parsing each workflow file at most once.
+type stepInputIndex struct {
+	files map[*WorkflowFile]map[int]map[string]int
+}
+
+func newStepInputIndex() *stepInputIndex {
+	return &stepInputIndex{files: make(map[*WorkflowFile]map[int]map[string]int)}
+}
+
+// For returns the inputs passed to a usage keyed by name, with the 1-based
+// line number of each key.
+func (idx *stepInputIndex) For(usage *ActionUsage) map[string]int {
+	byLine, ok := idx.files[usage.File]
+	if !ok {
+		byLine = parseStepInputs(strings.Join(usage.File.Lines, "\n"))
+		idx.files[usage.File] = byLine
+	}
+	return byLine[usage.Line]
+}
+
+func parseStepInputs(content string) map[int]map[string]int {
+	result := make(map[int]map[string]int)
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &root); err != nil {
+		return result
+	}
+
+	var walk func(node *yaml.Node)
+	walk = func(node *yaml.Node) {
+		if node.Kind == yaml.MappingNode {
+			usesLine := -1
+			var with *yaml.Node
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key, value := node.Content[i], node.Content[i+1]
+				switch key.Value {
+				case "uses":
+					usesLine = key.Line - 1
+				case "with":
+					with = value
+				}
+			}
+			if usesLine >= 0 && with != nil && with.Kind == yaml.MappingNode {
+				inputs := make(map[string]int)
+				for i := 0; i+1 < len(with.Content); i += 2 {
+					inputs[with.Content[i].Value] = with.Content[i].Line
+				}
+				result[usesLine] = inputs
+			}
+		}
+		for _, child := range node.Content {
+			walk(child)
+		}
+	}
+	walk(&root)
+	return result
+}
+
+func cmdChanges(args []string) int {
+	client, err := api.DefaultRESTClient()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create GitHub client: %v\n", err)
+		return 1
+	}
+
+	files, err := loadWorkflowFiles()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load workflow files: %v\n", err)
+		return 1
+	}
+
+	exit := runChanges(os.Stdout, client, files, args)
+	return exit
+}
+
+func runChanges(w io.Writer, client restClient, files []*WorkflowFile, args []string) int {
+	fs := flag.NewFlagSet("changes", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	versionFlag := fs.String("version", "", "compare against a specific release tag instead of the latest release")
+
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "changes requires an owner/repo argument")
+		return 1
+	}
+
+	target := strings.ToLower(fs.Arg(0))
+	if strings.Count(target, "/") != 1 {
+		fmt.Fprintln(os.Stderr, "repository argument must be in the form owner/repo")
+		return 1
+	}
+
+	var usages []*ActionUsage
+	for _, usage := range allUsages(files) {
+		if usage.Spec.RepoKey() == target {
+			usages = append(usages, usage)
+		}
+	}
+	if len(usages) == 0 {
+		fmt.Fprintf(os.Stderr, "repository %s not referenced in workflows or composite actions\n", target)
+		return 1
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load configuration: %v\n", err)
+		return 1
+	}
+
+	owner, repo := usages[0].Spec.Owner, usages[0].Spec.Repo
+	resolver := NewTagResolver(client)
+	families, err := splitTagFamilies(resolver, &repoRecord{Owner: owner, Repo: repo, Usages: usages}, cfg.TagPrefixes)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to determine tag prefixes for %s/%s: %v\n", owner, repo, err)
+		return 1
+	}
+
+	// Each tag family is compared with its own release, so actions published
+	// from one repository under different prefixes are not mixed up.
+	inputs := newStepInputIndex()
+	for _, family := range families {
+		version, commit, err := determineFamilyVersion(client, resolver, owner, repo, family.Prefix, *versionFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to resolve target version for %s/%s: %v\n", owner, repo, err)
+			return 1
+		}
+
+		changes, errs := collectActionChanges(client, family.Usages, version, commit, inputs)
+		for _, err := range errs {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+		if len(changes) == 0 && len(errs) == 0 {
+			fmt.Fprintf(w, "All references to %s/%s are already at %s (%s).\n", owner, repo, version, shortSHA(commit))
+			continue
+		}
+		for _, change := range changes {
+			writeActionChanges(w, change)
+			writeActionChangeWarnings(w, change)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const setupNodeV3 = `name: setup-node
+inputs:
+  node-version:
+    description: version
+  always-auth:
+    description: auth
+    default: false
+outputs:
+  cache-hit:
+    description: hit
+runs:
+  using: node16
+`
+
+const setupNodeV4 = `name: setup-node
+inputs:
+  node-version:
+    description: version
+  token:
+    description: token
+    required: true
+outputs:
+  cache-hit:
+    description: hit
+  node-version:
+    description: resolved version
+runs:
+  using: node20
+`
+
+func TestRunChanges(t *testing.T) {
+	t.Parallel()
+	const oldCommit = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	const newCommit = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+
+	mock := newMockRESTClient(t).
+		withJSON("repos/actions/setup-node/releases/latest", map[string]interface{}{"tag_name": "v4.0.0"}).
+		withJSON("repos/actions/setup-node/git/ref/tags/v4.0.0", map[string]interface{}{
+			"object": map[string]interface{}{"sha": newCommit, "type": "commit"},
+		}).
+		withFile("actions/setup-node", "action.yml", oldCommit, setupNodeV3).
+		withFile("actions/setup-node", "action.yml", newCommit, setupNodeV4)
+
+	wf := buildWorkflowFileFromLines(t,
+		`    steps:`,
+		`      - uses: actions/setup-node@`+oldCommit+` # v3.8.1`,
+		`        with:`,
+		`          node-version: 20`,
+		`          always-auth: true`,
+	)
+
+	var out bytes.Buffer
+	if exit := runChanges(&out, mock, []*WorkflowFile{wf}, []string{"actions/setup-node"}); exit != 0 {
+		t.Fatalf("runChanges exit = %d, want 0", exit)
+	}
+
+	report := out.String()
+	for _, want := range []string{
+		"actions/setup-node v3.8.1 -> v4.0.0",
+		"runs.using changed: node16 -> node20",
+		"input `always-auth` removed",
+		"input `token` added",
+		"input `token` is now required",
+		"output `node-version` added",
+		"warning: input `always-auth` used in " + wf.Path + ":5 was removed in v4.0.0",
+		"warning: input `token` is required in v4.0.0 but not set by " + wf.Path + ":2",
+	} {
+		if !strings.Contains(report, want) {
+			t.Fatalf("report missing %q:\n%s", want, report)
+		}
+	}
+	if wf.changed {
+		t.Fatal("changes must not modify workflow files")
+	}
+}
+
+func TestParseStepInputs(t *testing.T) {
+	t.Parallel()
+	content := strings.Join([]string{
+		`jobs:`,
+		`  build:`,
+		`    steps:`,
+		`      - uses: actions/checkout@v4`,
+		`      - name: setup`,
+		`        uses: actions/setup-go@v5`,
+		`        with:`,
+		`          go-version: "1.25"`,
+	}, "\n")
+	inputs := parseStepInputs(content)
+	if _, ok := inputs[3]; ok {
+		t.Fatal("checkout step should have no inputs")
+	}
+	if line := inputs[5]["go-version"]; line != 8 {
+		t.Fatalf("go-version line = %d, want 8", line)
+	}
+}
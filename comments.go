@@ -0,0 +1,250 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+)
+
+// commentStyle is the way a version is written in the comment after a pinned
+// ref, such as "# v4", "# tag=v4", "# pin@v4" or "# renovate: tag=v4".
+type commentStyle string
+
+const (
+	commentStylePlain    commentStyle = "plain"
+	commentStyleTag      commentStyle = "tag"
+	commentStylePin      commentStyle = "pin"
+	commentStyleRenovate commentStyle = "renovate"
+)
+
+func parseCommentStyle(value string) (commentStyle, bool) {
+	switch style := commentStyle(strings.ToLower(strings.TrimSpace(value))); style {
+	case commentStylePlain, commentStyleTag, commentStylePin, commentStyleRenovate:
+		return style, true
+	default:
+		return "", false
+	}
+}
+
+// versionComment locates the version in a trimmed comment. End is the offset
+// just past the styled token, so comment[:End] is "tag=v4" for "tag=v4 keep".
+// Exact is the resolved tag recorded after a floating spec, as in
+// "v4 (v4.2.1)", and ExactEnd is the offset just past it (End when absent).
+type versionComment struct {
+	Style    commentStyle
+	Version  string
+	End      int
+	Exact    string
+	ExactEnd int
+}
+
+const renovatePrefix = "renovate:"
+
+// parseVersionComment recognizes the supported comment styles. Plain comments
+// treat their first field as the version; Renovate annotations without a tag,
+// such as "renovate: datasource=github-tags", carry no version.
+func parseVersionComment(comment string) versionComment {
+	offset := 0
+	style := commentStylePlain
+	if len(comment) >= len(renovatePrefix) && strings.EqualFold(comment[:len(renovatePrefix)], renovatePrefix) {
+		rest := comment[len(renovatePrefix):]
+		offset = len(comment) - len(strings.TrimLeft(rest, " \t"))
+		style = commentStyleRenovate
+	}
+
+	fields := strings.Fields(comment[offset:])
+	if len(fields) == 0 {
+		return versionComment{}
+	}
+	token := fields[0]
+	end := offset + len(token)
+	lower := strings.ToLower(token)
+
+	var version string
+	switch {
+	case strings.HasPrefix(lower, "tag="):
+		version = token[len("tag="):]
+		if style == commentStylePlain {
+			style = commentStyleTag
+		}
+	case strings.HasPrefix(lower, "pin@") && style == commentStylePlain:
+		version = token[len("pin@"):]
+		style = commentStylePin
+	case style == commentStyleRenovate && strings.Contains(token, "="):
+		return versionComment{}
+	default:
+		version = token
+	}
+	if version == "" {
+		return versionComment{}
+	}
+
+	parsed := versionComment{Style: style, Version: version, End: end, ExactEnd: end}
+	rest := comment[end:]
+	if next := strings.Fields(rest); len(next) > 0 && strings.HasPrefix(next[0], "(") && strings.HasSuffix(next[0], ")") {
+		if exact := next[0][1 : len(next[0])-1]; isSemverTag(exact) {
+			parsed.Exact = exact
+			parsed.ExactEnd = end + strings.Index(rest, next[0]) + len(next[0])
+		}
+	}
+	return parsed
+}
+
+func isSemverTag(tag string) bool {
+	_, ok := parseTagVersion(tag)
+	return ok
+}
+
+// exactVersion returns the exact tag to record next to a usage's version
+// comment: always when requested, and otherwise only to keep an existing
+// suffix accurate. Only semantic version tags are recorded.
+func exactVersion(usage *ActionUsage, tag string, record bool) string {
+	if !isSemverTag(tag) {
+		return ""
+	}
+	if record || parseVersionComment(usage.Comment).Exact != "" {
+		return tag
+	}
+	return ""
+}
+
+func formatVersionComment(style commentStyle, version string) string {
+	switch style {
+	case commentStyleTag:
+		return "tag=" + version
+	case commentStylePin:
+		return "pin@" + version
+	case commentStyleRenovate:
+		return renovatePrefix + " tag=" + version
+	default:
+		return version
+	}
+}
+
+// commentStyleFlag registers --comment-style on fs. An empty value means the
+// configured style, or the existing style of each comment when none is set.
+func commentStyleFlag(fs *flag.FlagSet) *commentStyle {
+	var style commentStyle
+	fs.Func("comment-style", "write version comments as plain, tag, pin, or renovate", func(value string) error {
+		parsed, ok := parseCommentStyle(value)
+		if !ok {
+			return fmt.Errorf("expected plain, tag, pin, or renovate")
+		}
+		style = parsed
+		return nil
+	})
+	return &style
+}
+
+// effectiveCommentStyle returns the style requested on the command line,
+// falling back to the comment-style configuration setting.
+func effectiveCommentStyle(flagStyle commentStyle) (commentStyle, error) {
+	if flagStyle != "" {
+		return flagStyle, nil
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return "", fmt.Errorf("failed to load configuration: %w", err)
+	}
+	style, _ := parseCommentStyle(cfg.CommentStyle)
+	return style, nil
+}
+
+func cmdReformat(args []string) int {
+	files, err := loadWorkflowFiles()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load workflow files: %v\n", err)
+		return 1
+	}
+
+	if len(allUsages(files)) == 0 {
+		fmt.Println("No workflow or composite action usages found.")
+		return 0
+	}
+
+	// Reformatting is local; the client is only needed for --create-pr.
+	var client restClient
+	if rest, err := api.DefaultRESTClient(); err == nil {
+		client = rest
+	}
+
+	exit := runReformat(client, files, args)
+	return exit
+}
+
+// runReformat rewrites every version comment in the given style without
+// changing any refs.
+func runReformat(client restClient, files []*WorkflowFile, args []string) int {
+	fs := flag.NewFlagSet("reformat", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	styleFlag := commentStyleFlag(fs)
+
+	var publish publishOptions
+	publish.register(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+
+	if fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "reformat does not accept positional arguments")
+		return 1
+	}
+
+	style, err := effectiveCommentStyle(*styleFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if style == "" {
+		style = commentStylePlain
+	}
+
+	if err := publish.validate(files); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	var updated int
+	var filesChanged int
+	for _, file := range files {
+		for _, usage := range file.Uses {
+			current := parseVersionComment(usage.Comment)
+			if current.Version == "" || current.Style == style {
+				continue
+			}
+			// Plain comments are only versions when they start with one;
+			// free text such as "# keep this pinned" is left alone.
+			if kind, _ := classifyVersionSpec(current.Version); current.Style == commentStylePlain && kind == specUnknown {
+				continue
+			}
+			usage.Set(usage.Ref, usage.CommentWithVersion(current.Version, current.Exact, style))
+			updated++
+		}
+
+		if file.changed {
+			if err := file.Save(); err != nil {
+				fmt.Fprintf(os.Stderr, "failed to write %s: %v\n", file.Path, err)
+				return 1
+			}
+			filesChanged++
+		}
+	}
+
+	if updated == 0 {
+		fmt.Printf("All version comments already use the %s style.\n", style)
+		return 0
+	}
+
+	fmt.Printf("Reformatted %d version comment(s) across %d file(s).\n", updated, filesChanged)
+
+	if err := publish.publish(client, files, "reformat"); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
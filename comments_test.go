@@ -0,0 +1,104 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParseVersionComment(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		comment string
+		style   commentStyle
+		version string
+		rest    string
+	}{
+		{"v4.1.1", commentStylePlain, "v4.1.1", ""},
+		{"tag=v4 pinned by bot", commentStyleTag, "v4", "pinned by bot"},
+		{"pin@v4", commentStylePin, "v4", ""},
+		{"renovate: tag=v4.1.1", commentStyleRenovate, "v4.1.1", ""},
+		{"Renovate:v3", commentStyleRenovate, "v3", ""},
+		{"renovate: datasource=github-tags", "", "", "renovate: datasource=github-tags"},
+		{"v4 (v4.2.1) keep", commentStylePlain, "v4", "keep"},
+		{"v1 (pinned)", commentStylePlain, "v1", "(pinned)"},
+		{"", "", "", ""},
+	}
+	for _, tc := range cases {
+		parsed := parseVersionComment(tc.comment)
+		if parsed.Style != tc.style || parsed.Version != tc.version {
+			t.Fatalf("parseVersionComment(%q) = %+v, want style %q version %q", tc.comment, parsed, tc.style, tc.version)
+		}
+		version, rest := splitComment(tc.comment)
+		if version != tc.version || rest != tc.rest {
+			t.Fatalf("splitComment(%q) = (%q, %q), want (%q, %q)", tc.comment, version, rest, tc.version, tc.rest)
+		}
+	}
+}
+
+func TestCommentWithVersionStyles(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		comment string
+		style   commentStyle
+		want    string
+	}{
+		{"tag=v1 keep", "", "tag=v2 keep"},
+		{"pin@v1", "", "pin@v2"},
+		{"v1 keep", commentStyleRenovate, "renovate: tag=v2 keep"},
+		{"renovate: tag=v1", commentStylePlain, "v2"},
+		{"renovate: datasource=github-tags", commentStyleTag, "tag=v2 renovate: datasource=github-tags"},
+		{"", "", "v2"},
+	}
+	for _, tc := range cases {
+		usage := &ActionUsage{Comment: tc.comment}
+		if got := usage.CommentWithVersion("v2", "", tc.style); got != tc.want {
+			t.Fatalf("CommentWithVersion(%q, %q) = %q, want %q", tc.comment, tc.style, got, tc.want)
+		}
+	}
+
+	exactCases := []struct {
+		comment string
+		exact   string
+		want    string
+	}{
+		{"v2", "v2.1.0", "v2 (v2.1.0)"},
+		{"tag=v2  (v2.0.0) keep", "v2.1.0", "tag=v2  (v2.1.0) keep"},
+		{"v2 (v2.0.0) keep", "", "v2 keep"},
+		{"v2.1.0", "V2", "v2"},
+	}
+	for _, tc := range exactCases {
+		usage := &ActionUsage{Comment: tc.comment}
+		if got := usage.CommentWithVersion("v2", tc.exact, ""); got != tc.want {
+			t.Fatalf("CommentWithVersion(%q) with exact %q = %q, want %q", tc.comment, tc.exact, got, tc.want)
+		}
+	}
+}
+
+func TestRunReformat(t *testing.T) {
+	t.Parallel()
+	const checkoutCommit = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	wf := buildWorkflowFileFromLines(t,
+		"      - uses: actions/checkout@"+checkoutCommit+" # v4.1.1",
+		"      - uses: actions/setup-go@"+checkoutCommit+" # tag=v5 keep",
+		"      - uses: actions/cache@v4",
+		"      - uses: actions/cache@"+checkoutCommit+" # cache deps",
+		"      - uses: actions/setup-node@"+checkoutCommit+" # keep this pinned",
+	)
+
+	if exit := runReformat(nil, []*WorkflowFile{wf}, []string{"--comment-style", "pin"}); exit != 0 {
+		t.Fatalf("expected runReformat to succeed, got %d", exit)
+	}
+
+	content, err := os.ReadFile(wf.Path)
+	if err != nil {
+		t.Fatalf("failed to read workflow: %v", err)
+	}
+	want := "      - uses: actions/checkout@" + checkoutCommit + " # pin@v4.1.1\n" +
+		"      - uses: actions/setup-go@" + checkoutCommit + " # pin@v5 keep\n" +
+		"      - uses: actions/cache@v4\n" +
+		"      - uses: actions/cache@" + checkoutCommit + " # cache deps\n" +
+		"      - uses: actions/setup-node@" + checkoutCommit + " # keep this pinned\n"
+	if string(content) != want {
+		t.Fatalf("unexpected content:\n%s", content)
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configPaths lists the locations checked for repository configuration, in
+// order of preference.
+var configPaths = []string{
+	".github/actions-versions.yml",
+	".github/actions-versions.yaml",
+}
+
+// Config holds repository-level settings read from
+// .github/actions-versions.yml.
+type Config struct {
+	Groups []GroupConfig `yaml:"groups"`
+	// CommentStyle is the style used when writing version comments: plain,
+	// tag, pin, or renovate. Empty keeps each comment's existing style.
+	CommentStyle string `yaml:"comment-style"`
+	Policy       Policy `yaml:"policy"`
+	// TagPrefixes maps owner/repo/path to the prefix of the tags its
+	// releases are published under, such as "setup-foo/" or "foo@", for
+	// repositories hosting several actions. Actions in a sub-path without an
+	// entry have their prefix detected from the repository's tags.
+	TagPrefixes map[string]string `yaml:"tag-prefixes"`
+}
+
+// Policy restricts which actions may be used, mirroring the repository and
+// organization "allowed actions" setting. Patterns are globs over owner/repo
+// or owner/repo/path, optionally followed by @ref.
+//
+// Actions from TrustedOwners may stay on tags or branches instead of being
+// pinned to a commit SHA; with CheckTrustedRefs, verify still confirms that
+// those refs exist.
+type Policy struct {
+	Allowed          []string `yaml:"allowed"`
+	Denied           []string `yaml:"denied"`
+	TrustedOwners    []string `yaml:"trusted-owners"`
+	CheckTrustedRefs bool     `yaml:"check-trusted-refs"`
+}
+
+// Trusted reports whether the action's owner matches a trusted owner glob.
+func (p Policy) Trusted(spec ActionSpec) bool {
+	owner := strings.ToLower(spec.Owner)
+	for _, pattern := range p.TrustedOwners {
+		if ok, err := path.Match(strings.ToLower(strings.TrimSpace(pattern)), owner); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// GroupConfig describes a set of actions whose updates are proposed together
+// in a single pull request.
+type GroupConfig struct {
+	Name        string   `yaml:"name"`
+	Patterns    []string `yaml:"patterns"`
+	UpdateTypes []string `yaml:"update-types"`
+}
+
+// loadConfig reads the repository configuration. A missing file yields an
+// empty configuration.
+func loadConfig() (*Config, error) {
+	for _, candidate := range configPaths {
+		content, err := os.ReadFile(candidate)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		cfg, err := parseConfig(content)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", candidate, err)
+		}
+		return cfg, nil
+	}
+	return &Config{}, nil
+}
+
+func parseConfig(content []byte) (*Config, error) {
+	var cfg Config
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if cfg.CommentStyle != "" {
+		if _, ok := parseCommentStyle(cfg.CommentStyle); !ok {
+			return nil, fmt.Errorf("unknown comment-style %q (expected plain, tag, pin, or renovate)", cfg.CommentStyle)
+		}
+	}
+	for action, prefix := range cfg.TagPrefixes {
+		if strings.Count(action, "/") < 1 || strings.TrimSpace(prefix) == "" {
+			return nil, fmt.Errorf("tag-prefixes entry %q must map owner/repo/path to a non-empty prefix", action)
+		}
+	}
+	for i, group := range cfg.Groups {
+		if strings.TrimSpace(group.Name) == "" {
+			return nil, fmt.Errorf("group %d is missing a name", i+1)
+		}
+		for _, updateType := range group.UpdateTypes {
+			if level, ok := parseUpgradeLevel(updateType); !ok || level == levelAny {
+				return nil, fmt.Errorf("group %s has unknown update type %q", group.Name, updateType)
+			}
+		}
+	}
+	return &cfg, nil
+}
+
+// matchActionPattern reports whether an action matches a glob pattern such as
+// actions/*, github/codeql-action/* or my-org/*. Patterns are matched against
+// both owner/repo and the full owner/repo/path, case-insensitively.
+func matchActionPattern(pattern string, spec ActionSpec) bool {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	if pattern == "" {
+		return false
+	}
+	for _, candidate := range []string{spec.RepoKey(), strings.ToLower(spec.FullPath())} {
+		if ok, err := path.Match(pattern, candidate); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPolicyPattern matches a usage against a policy pattern such as
+// actions/*, my-org/deploy or octo/tool@v2. A ref glob is checked against the
+// usage's ref and the version in its comment.
+func matchPolicyPattern(pattern string, usage *ActionUsage) bool {
+	name, ref, hasRef := strings.Cut(strings.TrimSpace(pattern), "@")
+	if !matchActionPattern(name, usage.Spec) {
+		return false
+	}
+	if !hasRef {
+		return true
+	}
+	version, _ := splitComment(usage.Comment)
+	for _, candidate := range []string{usage.Ref, version} {
+		if candidate == "" {
+			continue
+		}
+		if ok, err := path.Match(ref, candidate); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Violation explains why the policy rejects a usage, or returns an empty
+// string when it is permitted. Denied patterns win over allowed ones; an
+// empty allow list permits everything not denied.
+func (p Policy) Violation(usage *ActionUsage) string {
+	for _, pattern := range p.Denied {
+		if matchPolicyPattern(pattern, usage) {
+			return fmt.Sprintf("is denied by policy pattern %q", pattern)
+		}
+	}
+	if len(p.Allowed) == 0 {
+		return ""
+	}
+	for _, pattern := range p.Allowed {
+		if matchPolicyPattern(pattern, usage) {
+			return ""
+		}
+	}
+	return "is not in the policy's allowed actions"
+}
+
+// Matches reports whether a change of the given level to the action belongs
+// to the group. Groups without update types accept every level.
+func (g GroupConfig) Matches(spec ActionSpec, level upgradeLevel) bool {
+	matched := false
+	for _, pattern := range g.Patterns {
+		if matchActionPattern(pattern, spec) {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return false
+	}
+	if len(g.UpdateTypes) == 0 {
+		return true
+	}
+	for _, updateType := range g.UpdateTypes {
+		if allowed, _ := parseUpgradeLevel(updateType); allowed == level {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+)
+
+func TestParseConfig(t *testing.T) {
+	t.Parallel()
+	cfg, err := parseConfig([]byte(`
+groups:
+  - name: first-party
+    patterns: ["actions/*", "github/*"]
+    update-types: [patch, minor]
+`))
+	if err != nil {
+		t.Fatalf("parseConfig error: %v", err)
+	}
+	if len(cfg.Groups) != 1 || cfg.Groups[0].Name != "first-party" || len(cfg.Groups[0].Patterns) != 2 {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+
+	if _, err := parseConfig([]byte("")); err != nil {
+		t.Fatalf("empty config should parse: %v", err)
+	}
+	if _, err := parseConfig([]byte("groups:\n  - name: x\n    update-types: [huge]\n")); err == nil {
+		t.Fatal("expected unknown update type to fail")
+	}
+	if cfg, err := parseConfig([]byte("comment-style: tag\n")); err != nil || cfg.CommentStyle != "tag" {
+		t.Fatalf("expected comment-style to parse, got %+v (%v)", cfg, err)
+	}
+	if _, err := parseConfig([]byte("comment-style: fancy\n")); err == nil {
+		t.Fatal("expected unknown comment style to fail")
+	}
+	if cfg, err := parseConfig([]byte("tag-prefixes:\n  octo/tools/setup-foo: setup-foo/\n")); err != nil || cfg.TagPrefixes["octo/tools/setup-foo"] != "setup-foo/" {
+		t.Fatalf("expected tag-prefixes to parse, got %+v (%v)", cfg, err)
+	}
+	if _, err := parseConfig([]byte("tag-prefixes:\n  octo/tools/setup-foo: \"\"\n")); err == nil {
+		t.Fatal("expected an empty tag prefix to fail")
+	}
+	if _, err := parseConfig([]byte("grops: []\n")); err == nil {
+		t.Fatal("expected unknown keys to fail")
+	}
+}
+
+func TestMatchActionPattern(t *testing.T) {
+	t.Parallel()
+	spec := ActionSpec{Owner: "GitHub", Repo: "codeql-action", Path: "init"}
+	for _, pattern := range []string{"github/*", "github/codeql-action", "github/codeql-action/*"} {
+		if !matchActionPattern(pattern, spec) {
+			t.Fatalf("expected %q to match %s", pattern, spec.FullPath())
+		}
+	}
+	if matchActionPattern("actions/*", spec) {
+		t.Fatal("actions/* should not match github/codeql-action")
+	}
+}
+
+func TestPolicyViolation(t *testing.T) {
+	t.Parallel()
+	cfg, err := parseConfig([]byte(`
+policy:
+  allowed: ["actions/*", "github/*", "my-org/*", "octo/tool@v2*"]
+  denied: ["actions/labeler"]
+`))
+	if err != nil {
+		t.Fatalf("parseConfig error: %v", err)
+	}
+
+	cases := []struct {
+		line    string
+		allowed bool
+	}{
+		{"- uses: actions/checkout@v4", true},
+		{"- uses: github/codeql-action/init@" + strings.Repeat("a", 40) + " # v3", true},
+		{"- uses: actions/labeler@v5", false},
+		{"- uses: octo/tool@" + strings.Repeat("b", 40) + " # v2.1.0", true},
+		{"- uses: octo/tool@v1", false},
+		{"- uses: tj-actions/changed-files@v45", false},
+	}
+	for _, tc := range cases {
+		usage, ok := parseUsesLine(tc.line)
+		if !ok {
+			t.Fatalf("parseUsesLine failed for %q", tc.line)
+		}
+		if violation := cfg.Policy.Violation(usage); (violation == "") != tc.allowed {
+			t.Fatalf("%s: violation = %q, want allowed=%v", tc.line, violation, tc.allowed)
+		}
+	}
+
+	if (Policy{}).Violation(&ActionUsage{Spec: ActionSpec{Owner: "any", Repo: "thing"}}) != "" {
+		t.Fatal("an empty policy should allow everything")
+	}
+}
+
+func TestVerifyTrustedOwners(t *testing.T) {
+	t.Parallel()
+	const commit = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+
+	mock := newMockRESTClient(t).
+		withJSON("repos/my-org/deploy", map[string]interface{}{"full_name": "my-org/deploy"}).
+		withJSON("repos/my-org/deploy/commits/v1", map[string]interface{}{"sha": commit}).
+		withError("repos/my-org/deploy/commits/missing", &api.HTTPError{StatusCode: 422, RequestURL: &url.URL{Path: "repos/my-org/deploy/commits/missing"}}).
+		withJSON("repos/actions/checkout", map[string]interface{}{"full_name": "actions/checkout"})
+
+	cfg := &Config{Policy: Policy{TrustedOwners: []string{"my-org"}, CheckTrustedRefs: true}}
+	if !cfg.Policy.Trusted(ActionSpec{Owner: "My-Org", Repo: "deploy"}) {
+		t.Fatal("expected owner match to be case-insensitive")
+	}
+
+	trusted := buildWorkflowFile(t, "      - uses: my-org/deploy@v1")
+	if exit := verifyFiles(mock, []*WorkflowFile{trusted}, cfg); exit != 0 {
+		t.Fatalf("expected trusted tag reference to pass, got %d", exit)
+	}
+
+	missing := buildWorkflowFile(t, "      - uses: my-org/deploy@missing")
+	if exit := verifyFiles(mock, []*WorkflowFile{missing}, cfg); exit == 0 {
+		t.Fatal("expected unresolvable trusted ref to fail")
+	}
+
+	untrusted := buildWorkflowFile(t, "      - uses: actions/checkout@v4")
+	if exit := verifyFiles(mock, []*WorkflowFile{untrusted}, cfg); exit == 0 {
+		t.Fatal("expected untrusted tag reference to fail")
+	}
+}
+
+func TestUpdateSkipsTrustedOwners(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, ".github"), 0o755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	config := "policy:\n  trusted-owners: [my-org]\n"
+	if err := os.WriteFile(filepath.Join(dir, ".github", "actions-versions.yml"), []byte(config), 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	t.Chdir(dir)
+
+	// Any lookup would fail the test: trusted tag references are left as is.
+	mock := newMockRESTClient(t)
+	const line = "      - uses: my-org/deploy@v1.2.0 # v1.2.0"
+
+	wf := buildWorkflowFile(t, line)
+	if exit := runUpdate(mock, []*WorkflowFile{wf}, []string{"--all"}); exit != 0 {
+		t.Fatalf("runUpdate exit = %d, want 0", exit)
+	}
+	if wf.Lines[0] != line {
+		t.Fatalf("update rewrote a trusted reference: %q", wf.Lines[0])
+	}
+
+	if exit := runUpgrade(mock, []*WorkflowFile{wf}, []string{"my-org/deploy"}); exit != 0 {
+		t.Fatalf("runUpgrade exit = %d, want 0", exit)
+	}
+	if wf.Lines[0] != line {
+		t.Fatalf("upgrade rewrote a trusted reference: %q", wf.Lines[0])
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// gitRunner runs a git subcommand and returns its standard output.
+type gitRunner func(args ...string) (string, error)
+
+// newGitRunner returns a runner that invokes the local git binary in dir, or
+// in the current directory when dir is empty.
+func newGitRunner(dir string) gitRunner {
+	return func(args ...string) (string, error) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			message := strings.TrimSpace(stderr.String())
+			if message == "" {
+				message = err.Error()
+			}
+			return "", fmt.Errorf("git %s: %s", args[0], message)
+		}
+		return stdout.String(), nil
+	}
+}
+
+// repoRelativePaths maps workflow file paths to paths relative to the root of
+// the git work tree, keyed by the relative path.
+func repoRelativePaths(git gitRunner, dir string, files []*WorkflowFile) (map[string]*WorkflowFile, error) {
+	toplevel, err := git("rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	root, err := filepath.EvalSymlinks(strings.TrimSpace(toplevel))
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make(map[string]*WorkflowFile, len(files))
+	for _, file := range files {
+		abs := file.Path
+		if !filepath.IsAbs(abs) {
+			abs = filepath.Join(dir, abs)
+		}
+		abs, err := filepath.Abs(abs)
+		if err != nil {
+			return nil, err
+		}
+		if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+			abs = resolved
+		}
+		rel, err := filepath.Rel(root, abs)
+		if err != nil {
+			return nil, err
+		}
+		paths[filepath.ToSlash(rel)] = file
+	}
+	return paths, nil
+}
+
+// checkStagedFiles refuses to continue when the index already holds changes
+// to anything other than the given rewritten workflow files, so --commit never
+// sweeps unrelated work, including edits to workflows the command did not
+// touch, into its commit.
+func checkStagedFiles(git gitRunner, dir string, files []*WorkflowFile) error {
+	known, err := repoRelativePaths(git, dir, files)
+	if err != nil {
+		return err
+	}
+	staged, err := git("diff", "--cached", "--name-only", "-z")
+	if err != nil {
+		return err
+	}
+	var unrelated []string
+	for _, name := range strings.Split(staged, "\x00") {
+		if name == "" {
+			continue
+		}
+		if _, ok := known[name]; !ok {
+			unrelated = append(unrelated, name)
+		}
+	}
+	if len(unrelated) > 0 {
+		return fmt.Errorf("refusing to commit: unrelated files are staged (%s)", strings.Join(unrelated, ", "))
+	}
+	return nil
+}
+
+// commitChanges stages the changed workflow files and commits them with a
+// conventional message summarizing each action bump.
+func commitChanges(git gitRunner, dir string, files []*WorkflowFile) (string, error) {
+	changed := changedFiles(files)
+	if len(changed) == 0 {
+		return "", nil
+	}
+	if err := checkStagedFiles(git, dir, changed); err != nil {
+		return "", err
+	}
+
+	paths, err := repoRelativePaths(git, dir, changed)
+	if err != nil {
+		return "", err
+	}
+	var pathspec []string
+	for name := range paths {
+		pathspec = append(pathspec, ":(top,literal)"+name)
+	}
+
+	if _, err := git(append([]string{"add", "--"}, pathspec...)...); err != nil {
+		return "", err
+	}
+	message := commitMessage(summarizeChanges(changed))
+	if _, err := git(append([]string{"commit", "--quiet", "-m", message, "--"}, pathspec...)...); err != nil {
+		return "", err
+	}
+	sha, err := git("rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(sha), nil
+}
+
+func commitMessage(bumps []*actionBump) string {
+	var b strings.Builder
+	b.WriteString("ci: ")
+	b.WriteString(lowerFirst(changeTitle("", bumps)))
+	if len(bumps) > 1 {
+		b.WriteString("\n\n")
+		for _, bump := range bumps {
+			fmt.Fprintf(&b, "- %s %s -> %s (%s)\n", bump.Action, bump.fromLabel(), bump.toLabel(), refLabel(bump.ToRef))
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func lowerFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError {
+		return s
+	}
+	return string(unicode.ToLower(r)) + s[size:]
+}
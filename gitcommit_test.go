@@ -0,0 +1,139 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func initGitRepo(t *testing.T) (string, gitRunner) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git binary not available")
+	}
+	dir := t.TempDir()
+	git := newGitRunner(dir)
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"config", "user.name", "Test"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "commit.gpgsign", "false"},
+	} {
+		if _, err := git(args...); err != nil {
+			t.Fatalf("git setup failed: %v", err)
+		}
+	}
+	return dir, git
+}
+
+func TestCommitChanges(t *testing.T) {
+	t.Parallel()
+	const oldCommit = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	const newCommit = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+
+	dir, git := initGitRepo(t)
+	workflowDir := filepath.Join(dir, ".github", "workflows")
+	if err := os.MkdirAll(workflowDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	line := `      - uses: actions/checkout@` + oldCommit + ` # v4.1.0`
+	workflowPath := filepath.Join(workflowDir, "ci.yml")
+	if err := os.WriteFile(workflowPath, []byte(line+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("readme\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := git("add", "."); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := git("commit", "--quiet", "-m", "initial"); err != nil {
+		t.Fatal(err)
+	}
+
+	wf := buildWorkflowFile(t, line)
+	wf.Path = workflowPath
+	wf.Uses[0].Set(newCommit, "v4.2.0")
+	if err := wf.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("edited\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := git("add", "README.md"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := commitChanges(git, dir, []*WorkflowFile{wf}); err == nil || !strings.Contains(err.Error(), "README.md") {
+		t.Fatalf("expected refusal naming README.md, got %v", err)
+	}
+
+	if _, err := git("reset", "--quiet", "README.md"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A staged edit to a workflow the command did not rewrite is unrelated too.
+	otherPath := filepath.Join(workflowDir, "other.yml")
+	if err := os.WriteFile(otherPath, []byte("on: push\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := git("add", otherPath); err != nil {
+		t.Fatal(err)
+	}
+	other := newWorkflowFile(otherPath, []byte("on: push\n"))
+	if _, err := commitChanges(git, dir, []*WorkflowFile{wf, other}); err == nil || !strings.Contains(err.Error(), "other.yml") {
+		t.Fatalf("expected refusal naming other.yml, got %v", err)
+	}
+	if _, err := git("rm", "--quiet", "--cached", otherPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(otherPath); err != nil {
+		t.Fatal(err)
+	}
+
+	sha, err := commitChanges(git, dir, []*WorkflowFile{wf})
+	if err != nil {
+		t.Fatalf("commitChanges error: %v", err)
+	}
+	if !isFullCommitSHA(sha) {
+		t.Fatalf("unexpected commit sha %q", sha)
+	}
+
+	message, err := git("log", "-1", "--format=%B")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(message) != "ci: bump actions/checkout from v4.1.0 to v4.2.0" {
+		t.Fatalf("unexpected commit message %q", message)
+	}
+	files, err := git("show", "--name-only", "--format=", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(files) != ".github/workflows/ci.yml" {
+		t.Fatalf("commit should only contain the workflow, got %q", files)
+	}
+	status, err := git("status", "--porcelain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(status) != "M README.md" {
+		t.Fatalf("unrelated changes should remain uncommitted, got %q", status)
+	}
+}
+
+func TestCommitMessageMultipleBumps(t *testing.T) {
+	t.Parallel()
+	message := commitMessage([]*actionBump{
+		{Action: "actions/checkout", FromTag: "v4", ToTag: "v5", ToRef: "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"},
+		{Action: "actions/setup-go", FromTag: "v5.0.0", ToTag: "v5.1.0", ToRef: "cccccccccccccccccccccccccccccccccccccccc"},
+	})
+	want := "ci: update 2 GitHub Actions\n\n" +
+		"- actions/checkout v4 -> v5 (bbbbbbbbbbbb)\n" +
+		"- actions/setup-go v5.0.0 -> v5.1.0 (cccccccccccc)"
+	if message != want {
+		t.Fatalf("commitMessage =\n%s\nwant\n%s", message, want)
+	}
+}
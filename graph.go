@@ -0,0 +1,255 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	graphNodeWorkflow  = "workflow"
+	graphNodeComposite = "composite"
+	graphNodeAction    = "action"
+	graphNodeReusable  = "reusable-workflow"
+	graphNodeMissing   = "missing"
+)
+
+type graphNode struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+	Kind  string `json:"kind"`
+}
+
+type graphEdge struct {
+	From      string   `json:"from"`
+	To        string   `json:"to"`
+	Locations []string `json:"locations"`
+}
+
+type dependencyGraph struct {
+	Nodes []*graphNode `json:"nodes"`
+	Edges []*graphEdge `json:"edges"`
+}
+
+func cmdGraph(args []string) int {
+	files, err := loadWorkflowFiles()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load workflow files: %v\n", err)
+		return 1
+	}
+
+	return runGraph(os.Stdout, files, args)
+}
+
+func runGraph(w io.Writer, files []*WorkflowFile, args []string) int {
+	fs := flag.NewFlagSet("graph", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	format := fs.String("format", "dot", "output format (dot, mermaid, json)")
+
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+
+	if fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "graph does not accept positional arguments")
+		return 1
+	}
+
+	graph := buildDependencyGraph(files)
+
+	var err error
+	switch *format {
+	case "dot":
+		err = writeGraphDOT(w, graph)
+	case "mermaid":
+		err = writeGraphMermaid(w, graph)
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(graph)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown format %q (expected dot, mermaid, or json)\n", *format)
+		return 1
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write graph: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// buildDependencyGraph links every workflow and composite action file to the
+// local composite actions and reusable workflows it references and to the
+// remote actions it uses. Nodes and edges are sorted for stable output.
+func buildDependencyGraph(files []*WorkflowFile) *dependencyGraph {
+	nodes := make(map[string]*graphNode)
+	edges := make(map[string]*graphEdge)
+
+	addNode := func(node *graphNode) {
+		if _, ok := nodes[node.ID]; !ok {
+			nodes[node.ID] = node
+		}
+	}
+	addEdge := func(from, to, location string) {
+		key := from + "\x00" + to
+		edge, ok := edges[key]
+		if !ok {
+			edge = &graphEdge{From: from, To: to}
+			edges[key] = edge
+		}
+		edge.Locations = append(edge.Locations, location)
+	}
+
+	for _, file := range files {
+		addNode(fileGraphNode(file))
+	}
+
+	for _, file := range files {
+		from := repoRelativePath(file)
+		for _, local := range file.Local {
+			location := fmt.Sprintf("%s:%d", file.Path, local.LineNumber())
+			if target := resolveLocalTarget(files, local.Target()); target != nil {
+				addEdge(from, repoRelativePath(target), location)
+				continue
+			}
+			id := local.Target()
+			addNode(&graphNode{ID: id, Label: "./" + id, Kind: graphNodeMissing})
+			addEdge(from, id, location)
+		}
+		for _, usage := range file.Uses {
+			id := strings.ToLower(fmt.Sprintf("%s@%s", usage.Spec.FullPath(), usage.Ref))
+			version, _ := splitComment(usage.Comment)
+			if version == "" {
+				version = refLabel(usage.Ref)
+			}
+			kind := graphNodeAction
+			if usage.Kind() == usageKindReusableWorkflow {
+				kind = graphNodeReusable
+			}
+			addNode(&graphNode{ID: id, Label: fmt.Sprintf("%s@%s", usage.Spec.FullPath(), version), Kind: kind})
+			addEdge(from, id, fmt.Sprintf("%s:%d", file.Path, usage.LineNumber()))
+		}
+	}
+
+	graph := &dependencyGraph{Nodes: []*graphNode{}, Edges: []*graphEdge{}}
+	for _, node := range nodes {
+		graph.Nodes = append(graph.Nodes, node)
+	}
+	sort.Slice(graph.Nodes, func(i, j int) bool {
+		if graph.Nodes[i].Kind != graph.Nodes[j].Kind {
+			return graphKindOrder(graph.Nodes[i].Kind) < graphKindOrder(graph.Nodes[j].Kind)
+		}
+		return graph.Nodes[i].ID < graph.Nodes[j].ID
+	})
+	for _, edge := range edges {
+		graph.Edges = append(graph.Edges, edge)
+	}
+	sort.Slice(graph.Edges, func(i, j int) bool {
+		if graph.Edges[i].From != graph.Edges[j].From {
+			return graph.Edges[i].From < graph.Edges[j].From
+		}
+		return graph.Edges[i].To < graph.Edges[j].To
+	})
+	return graph
+}
+
+func graphKindOrder(kind string) int {
+	switch kind {
+	case graphNodeWorkflow:
+		return 0
+	case graphNodeComposite:
+		return 1
+	case graphNodeMissing:
+		return 2
+	default:
+		return 3
+	}
+}
+
+func fileGraphNode(file *WorkflowFile) *graphNode {
+	id := repoRelativePath(file)
+	if isActionMetadataFile(id) {
+		return &graphNode{ID: id, Label: "./" + pathDir(id), Kind: graphNodeComposite}
+	}
+	return &graphNode{ID: id, Label: id, Kind: graphNodeWorkflow}
+}
+
+func isActionMetadataFile(path string) bool {
+	base := path[strings.LastIndex(path, "/")+1:]
+	return base == "action.yml" || base == "action.yaml"
+}
+
+func pathDir(path string) string {
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		return path[:i]
+	}
+	return "."
+}
+
+func writeGraphDOT(w io.Writer, graph *dependencyGraph) error {
+	var b strings.Builder
+	b.WriteString("digraph actions {\n")
+	b.WriteString("  rankdir=LR;\n")
+	for _, node := range graph.Nodes {
+		shape := "ellipse"
+		style := ""
+		switch node.Kind {
+		case graphNodeWorkflow:
+			shape = "box"
+		case graphNodeComposite:
+			shape = "component"
+		case graphNodeReusable:
+			shape = "box"
+			style = ", style=rounded"
+		case graphNodeMissing:
+			shape = "box"
+			style = ", style=dashed"
+		}
+		fmt.Fprintf(&b, "  %s [label=%s, shape=%s%s];\n", dotQuote(node.ID), dotQuote(node.Label), shape, style)
+	}
+	for _, edge := range graph.Edges {
+		fmt.Fprintf(&b, "  %s -> %s;\n", dotQuote(edge.From), dotQuote(edge.To))
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func dotQuote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+// writeGraphMermaid writes a flowchart. Mermaid IDs cannot contain most
+// punctuation, so nodes are numbered in order and labelled with their names.
+func writeGraphMermaid(w io.Writer, graph *dependencyGraph) error {
+	ids := make(map[string]string, len(graph.Nodes))
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for i, node := range graph.Nodes {
+		id := fmt.Sprintf("n%d", i+1)
+		ids[node.ID] = id
+		label := strings.ReplaceAll(node.Label, `"`, "#quot;")
+		switch node.Kind {
+		case graphNodeWorkflow:
+			fmt.Fprintf(&b, "  %s[\"%s\"]\n", id, label)
+		case graphNodeComposite:
+			fmt.Fprintf(&b, "  %s[[\"%s\"]]\n", id, label)
+		case graphNodeReusable:
+			fmt.Fprintf(&b, "  %s(\"%s\")\n", id, label)
+		case graphNodeMissing:
+			fmt.Fprintf(&b, "  %s>\"%s (missing)\"]\n", id, label)
+		default:
+			fmt.Fprintf(&b, "  %s([\"%s\"])\n", id, label)
+		}
+	}
+	for _, edge := range graph.Edges {
+		fmt.Fprintf(&b, "  %s --> %s\n", ids[edge.From], ids[edge.To])
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func graphTestFiles() []*WorkflowFile {
+	return []*WorkflowFile{
+		newWorkflowFile(".github/workflows/ci.yml", []byte(strings.Join([]string{
+			"jobs:",
+			"  build:",
+			"    steps:",
+			"      - uses: actions/checkout@aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa # v4.1.0",
+			"      - uses: ./.github/actions/setup",
+			"      - uses: './.github/actions/gone'",
+			"  call:",
+			"    uses: ./.github/workflows/reusable.yml",
+		}, "\n")+"\n")),
+		newWorkflowFile(".github/workflows/reusable.yml", []byte(strings.Join([]string{
+			"on: workflow_call",
+			"jobs:",
+			"  lint:",
+			"    steps:",
+			"      - uses: actions/checkout@aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa # v4.1.0",
+		}, "\n")+"\n")),
+		newWorkflowFile(".github/actions/setup/action.yml", []byte(strings.Join([]string{
+			"runs:",
+			"  using: composite",
+			"  steps:",
+			"    - uses: actions/setup-node@v4",
+		}, "\n")+"\n")),
+	}
+}
+
+func TestParseLocalUsesLine(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		line string
+		path string
+		ok   bool
+	}{
+		{"      - uses: ./.github/actions/setup", "./.github/actions/setup", true},
+		{`    uses: "./.github/workflows/build.yml" # reusable`, "./.github/workflows/build.yml", true},
+		{"      - uses: actions/checkout@v4", "", false},
+		{"      - uses: ../shared/action", "../shared/action", true},
+		{"      - uses: docker://alpine:3", "", false},
+		{"      - uses: ./${{ matrix.action }}", "", false},
+	}
+	for _, tc := range cases {
+		path, ok := parseLocalUsesLine(tc.line)
+		if ok != tc.ok || path != tc.path {
+			t.Fatalf("parseLocalUsesLine(%q) = %q, %v; want %q, %v", tc.line, path, ok, tc.path, tc.ok)
+		}
+	}
+}
+
+func TestRunGraphJSON(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	if exit := runGraph(&out, graphTestFiles(), []string{"--format", "json"}); exit != 0 {
+		t.Fatalf("expected success, got %d", exit)
+	}
+
+	var graph dependencyGraph
+	if err := json.Unmarshal(out.Bytes(), &graph); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out.String())
+	}
+
+	kinds := make(map[string]string)
+	for _, node := range graph.Nodes {
+		kinds[node.ID] = node.Kind
+	}
+	expectedKinds := map[string]string{
+		".github/workflows/ci.yml":                                  graphNodeWorkflow,
+		".github/workflows/reusable.yml":                            graphNodeWorkflow,
+		".github/actions/setup/action.yml":                          graphNodeComposite,
+		".github/actions/gone":                                      graphNodeMissing,
+		"actions/checkout@aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa": graphNodeAction,
+		"actions/setup-node@v4":                                     graphNodeAction,
+	}
+	if len(kinds) != len(expectedKinds) {
+		t.Fatalf("expected %d nodes, got %+v", len(expectedKinds), kinds)
+	}
+	for id, kind := range expectedKinds {
+		if kinds[id] != kind {
+			t.Fatalf("expected node %s of kind %s, got %q", id, kind, kinds[id])
+		}
+	}
+
+	edges := make(map[string]bool)
+	for _, edge := range graph.Edges {
+		edges[edge.From+" -> "+edge.To] = true
+	}
+	for _, edge := range []string{
+		".github/workflows/ci.yml -> .github/actions/setup/action.yml",
+		".github/workflows/ci.yml -> .github/workflows/reusable.yml",
+		".github/workflows/ci.yml -> .github/actions/gone",
+		".github/actions/setup/action.yml -> actions/setup-node@v4",
+		".github/workflows/reusable.yml -> actions/checkout@aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+	} {
+		if !edges[edge] {
+			t.Fatalf("missing edge %s in %+v", edge, edges)
+		}
+	}
+}
+
+func TestRunGraphFormats(t *testing.T) {
+	t.Parallel()
+
+	var dot bytes.Buffer
+	if exit := runGraph(&dot, graphTestFiles(), nil); exit != 0 {
+		t.Fatalf("expected success, got %d", exit)
+	}
+	for _, want := range []string{
+		"digraph actions {",
+		`".github/actions/setup/action.yml" [label="./.github/actions/setup", shape=component];`,
+		`".github/workflows/ci.yml" -> "actions/checkout@aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa";`,
+		`[label="actions/checkout@v4.1.0", shape=ellipse]`,
+	} {
+		if !strings.Contains(dot.String(), want) {
+			t.Fatalf("expected DOT output to contain %q:\n%s", want, dot.String())
+		}
+	}
+
+	var mermaid bytes.Buffer
+	if exit := runGraph(&mermaid, graphTestFiles(), []string{"--format", "mermaid"}); exit != 0 {
+		t.Fatalf("expected success, got %d", exit)
+	}
+	for _, want := range []string{
+		"flowchart LR",
+		`n1[".github/workflows/ci.yml"]`,
+		`n3[["./.github/actions/setup"]]`,
+		"n1 --> n3",
+	} {
+		if !strings.Contains(mermaid.String(), want) {
+			t.Fatalf("expected Mermaid output to contain %q:\n%s", want, mermaid.String())
+		}
+	}
+
+	if exit := runGraph(&bytes.Buffer{}, graphTestFiles(), []string{"--format", "svg"}); exit != 1 {
+		t.Fatalf("expected an unknown format to fail, got %d", exit)
+	}
+}
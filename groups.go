@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Grouping strategies for --create-pr. Config-defined groups always take
+// precedence; the strategy decides where the remaining changes go.
+const (
+	groupByAll    = "all"
+	groupByLevel  = "level"
+	groupByAction = "action"
+)
+
+func validGroupStrategy(strategy string) bool {
+	switch strategy {
+	case groupByAll, groupByLevel, groupByAction:
+		return true
+	default:
+		return false
+	}
+}
+
+// changeGroup is a set of rewrites proposed together in one pull request. The
+// unnamed group holds everything when changes are not split up.
+type changeGroup struct {
+	Name    string
+	Changes []*usageChange
+}
+
+// changeLevel classifies a rewrite by the size of its version bump. Re-pins
+// that keep the same version count as patches; versions that cannot be
+// compared count as major so they are never folded into a smaller group.
+func changeLevel(change *usageChange) upgradeLevel {
+	fromTag, _ := splitComment(change.OldComment)
+	toTag, _ := splitComment(change.Usage.Comment)
+	from, fromOK := parseSemver(fromTag)
+	to, toOK := parseSemver(toTag)
+	if !fromOK || !toOK {
+		if strings.EqualFold(fromTag, toTag) {
+			return levelPatch
+		}
+		return levelMajor
+	}
+	if level := bumpLevel(from, to); level != levelAny {
+		return level
+	}
+	return levelPatch
+}
+
+// groupChanges partitions every recorded rewrite across the files into pull
+// request groups, preserving the order in which changes were made.
+func groupChanges(files []*WorkflowFile, strategy string, configured []GroupConfig) []*changeGroup {
+	groups := make(map[string]*changeGroup)
+	var order []string
+
+	for _, file := range files {
+		for _, change := range file.changes {
+			spec := change.Usage.Spec
+			level := changeLevel(change)
+
+			name := ""
+			matched := false
+			for _, group := range configured {
+				if group.Matches(spec, level) {
+					name = slugify(group.Name)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				switch strategy {
+				case groupByAction:
+					name = slugify(spec.RepoKey())
+				case groupByLevel:
+					if level == levelMajor {
+						name = "major-" + slugify(spec.RepoKey())
+					} else {
+						name = level.String()
+					}
+				}
+			}
+
+			group, ok := groups[name]
+			if !ok {
+				group = &changeGroup{Name: name}
+				groups[name] = group
+				order = append(order, name)
+			}
+			group.Changes = append(group.Changes, change)
+		}
+	}
+
+	result := make([]*changeGroup, 0, len(order))
+	for _, name := range order {
+		result = append(result, groups[name])
+	}
+	return result
+}
+
+// filesForGroup returns copies of the changed files containing only the
+// group's rewrites; every other rewritten line is restored to its original
+// content.
+func filesForGroup(files []*WorkflowFile, group *changeGroup) []*WorkflowFile {
+	include := make(map[*usageChange]struct{}, len(group.Changes))
+	for _, change := range group.Changes {
+		include[change] = struct{}{}
+	}
+
+	var result []*WorkflowFile
+	for _, file := range files {
+		lines := append([]string(nil), file.Lines...)
+		var kept []*usageChange
+		for _, change := range file.changes {
+			if _, ok := include[change]; ok {
+				kept = append(kept, change)
+				continue
+			}
+			lines[change.Usage.Line] = change.OldLine
+		}
+		if len(kept) == 0 {
+			continue
+		}
+		clone := file.withLines(lines)
+		clone.changed = true
+		clone.changes = kept
+		result = append(result, clone)
+	}
+	return result
+}
+
+// openPullRequest is the subset of an open pull request used to avoid
+// proposing a group twice.
+type openPullRequest struct {
+	Number  int    `json:"number"`
+	HTMLURL string `json:"html_url"`
+	Body    string `json:"body"`
+	Head    struct {
+		Ref string `json:"ref"`
+	} `json:"head"`
+	Base struct {
+		Ref string `json:"ref"`
+	} `json:"base"`
+}
+
+// openPullRequestBranches maps the head branch of every open pull request to
+// the pull request so previously opened groups are not proposed twice.
+func openPullRequestBranches(client restClient, owner, repo string) (map[string]*openPullRequest, error) {
+	branches := make(map[string]*openPullRequest)
+	for page := 1; ; page++ {
+		var pulls []*openPullRequest
+		path := fmt.Sprintf("repos/%s/%s/pulls?state=open&per_page=%d&page=%d", owner, repo, listPageSize, page)
+		if err := client.Get(path, &pulls); err != nil {
+			return nil, err
+		}
+		for _, pull := range pulls {
+			branches[pull.Head.Ref] = pull
+		}
+		if len(pulls) < listPageSize {
+			break
+		}
+	}
+	return branches, nil
+}
+
+var slugRE = regexp.MustCompile(`[^a-z0-9]+`)
+
+func slugify(value string) string {
+	return strings.Trim(slugRE.ReplaceAllString(strings.ToLower(value), "-"), "-")
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const (
+	groupOldSHA = "1111111111111111111111111111111111111111"
+	groupNewSHA = "2222222222222222222222222222222222222222"
+)
+
+// groupFixture rewrites one patch, one minor and two major bumps.
+func groupFixture(t *testing.T) []*WorkflowFile {
+	t.Helper()
+	wf := buildWorkflowFileFromLines(t,
+		`      - uses: actions/checkout@`+groupOldSHA+` # v4.1.0`,
+		`      - uses: actions/setup-go@`+groupOldSHA+` # v5.0.0`,
+		`      - uses: docker/login-action@`+groupOldSHA+` # v2.0.0`,
+		`      - uses: myorg/deploy@`+groupOldSHA+` # v1.0.0`,
+	)
+	wf.Path = ".github/workflows/ci.yml"
+	wf.Uses[0].Set(groupNewSHA, "v4.1.1")
+	wf.Uses[1].Set(groupNewSHA, "v5.1.0")
+	wf.Uses[2].Set(groupNewSHA, "v3.0.0")
+	wf.Uses[3].Set(groupNewSHA, "v2.0.0")
+	return []*WorkflowFile{wf}
+}
+
+func groupNames(groups []*changeGroup) []string {
+	var names []string
+	for _, group := range groups {
+		names = append(names, group.Name)
+	}
+	return names
+}
+
+func TestGroupChanges(t *testing.T) {
+	t.Parallel()
+	files := groupFixture(t)
+
+	if names := groupNames(groupChanges(files, groupByAll, nil)); strings.Join(names, ",") != "" || len(names) != 1 {
+		t.Fatalf("all strategy groups = %q, want a single unnamed group", names)
+	}
+
+	got := strings.Join(groupNames(groupChanges(files, groupByLevel, nil)), ",")
+	if got != "patch,minor,major-docker-login-action,major-myorg-deploy" {
+		t.Fatalf("level strategy groups = %q", got)
+	}
+
+	got = strings.Join(groupNames(groupChanges(files, groupByAction, nil)), ",")
+	if got != "actions-checkout,actions-setup-go,docker-login-action,myorg-deploy" {
+		t.Fatalf("action strategy groups = %q", got)
+	}
+
+	configured := []GroupConfig{{
+		Name:        "First party",
+		Patterns:    []string{"actions/*", "myorg/*"},
+		UpdateTypes: []string{"patch", "minor"},
+	}}
+	groups := groupChanges(files, groupByLevel, configured)
+	got = strings.Join(groupNames(groups), ",")
+	if got != "first-party,major-docker-login-action,major-myorg-deploy" {
+		t.Fatalf("configured groups = %q", got)
+	}
+	if len(groups[0].Changes) != 2 {
+		t.Fatalf("expected both first-party minor/patch bumps in one group, got %d", len(groups[0].Changes))
+	}
+}
+
+func TestFilesForGroup(t *testing.T) {
+	t.Parallel()
+	files := groupFixture(t)
+	groups := groupChanges(files, groupByAction, nil)
+
+	groupFiles := filesForGroup(files, groups[0])
+	if len(groupFiles) != 1 {
+		t.Fatalf("expected one file, got %d", len(groupFiles))
+	}
+	lines := groupFiles[0].Lines
+	if !strings.Contains(lines[0], groupNewSHA) {
+		t.Fatalf("group change missing: %q", lines[0])
+	}
+	for _, line := range lines[1:] {
+		if !strings.Contains(line, groupOldSHA) {
+			t.Fatalf("changes outside the group should be reverted: %q", line)
+		}
+	}
+	if !strings.Contains(files[0].Lines[1], groupNewSHA) {
+		t.Fatal("filesForGroup must not modify the original file")
+	}
+}
+
+func TestPublishPullRequestsDeduplicates(t *testing.T) {
+	t.Parallel()
+	files := groupFixture(t)
+	gh := newFakeGitHub(t)
+	client := gh.client()
+
+	if err := publishPullRequests(client, "octo", "repo", "update", files, "", groupByLevel, nil); err != nil {
+		t.Fatalf("first publish error: %v", err)
+	}
+	if len(gh.pulls) != 4 {
+		t.Fatalf("expected 4 pull requests, got %d", len(gh.pulls))
+	}
+	head := gh.pulls[0]["head"].(map[string]interface{})["ref"].(string)
+	if !strings.HasPrefix(head, "actions-versions/update-patch-") {
+		t.Fatalf("unexpected branch %q", head)
+	}
+	if title := gh.pulls[2]["title"]; title != "Bump docker/login-action from v2.0.0 to v3.0.0" {
+		t.Fatalf("unexpected major bump title %q", title)
+	}
+
+	if err := publishPullRequests(client, "octo", "repo", "update", files, "", groupByLevel, nil); err != nil {
+		t.Fatalf("second publish error: %v", err)
+	}
+	if len(gh.pulls) != 4 {
+		t.Fatalf("already-open groups should be skipped, got %d pull requests", len(gh.pulls))
+	}
+}
+
+func TestPublishPullRequestsUpdatesGroup(t *testing.T) {
+	t.Parallel()
+	const newerSHA = "3333333333333333333333333333333333333333"
+	gh := newFakeGitHub(t)
+	client := gh.client()
+
+	rewrite := func(commit, tag string) []*WorkflowFile {
+		wf := buildWorkflowFile(t, `      - uses: actions/checkout@`+groupOldSHA+` # v4.1.0`)
+		wf.Path = ".github/workflows/ci.yml"
+		wf.Uses[0].Set(commit, tag)
+		return []*WorkflowFile{wf}
+	}
+
+	if err := publishPullRequests(client, "octo", "repo", "update", rewrite(groupNewSHA, "v4.1.1"), "", groupByAll, nil); err != nil {
+		t.Fatalf("first publish error: %v", err)
+	}
+	head := gh.pulls[0]["head"].(map[string]interface{})["ref"].(string)
+	previous := gh.refs["refs/heads/"+head]
+
+	// A newer release replaces the open pull request's changes instead of
+	// opening a second pull request for the group.
+	if err := publishPullRequests(client, "octo", "repo", "update", rewrite(newerSHA, "v4.1.2"), "", groupByAll, nil); err != nil {
+		t.Fatalf("second publish error: %v", err)
+	}
+	if len(gh.pulls) != 1 {
+		t.Fatalf("expected the open pull request to be updated, got %d pull requests", len(gh.pulls))
+	}
+	if title := gh.pulls[0]["title"]; title != "Bump actions/checkout from v4.1.0 to v4.1.2" {
+		t.Fatalf("unexpected title after update %q", title)
+	}
+	if ref := gh.refs["refs/heads/"+head]; ref == previous {
+		t.Fatalf("expected branch %s to move to a new commit", head)
+	}
+	content := gh.blobs[gh.trees[len(gh.trees)-1]["tree"].([]interface{})[0].(map[string]interface{})["sha"].(string)]
+	if !strings.Contains(content, newerSHA+" # v4.1.2") {
+		t.Fatalf("updated branch does not contain the newer release:\n%s", content)
+	}
+
+	if err := publishPullRequests(client, "octo", "repo", "update", rewrite(newerSHA, "v4.1.2"), "", groupByAll, nil); err != nil {
+		t.Fatalf("third publish error: %v", err)
+	}
+	if len(gh.commits) != 2 {
+		t.Fatalf("an up-to-date pull request should not be rewritten, got %d commits", len(gh.commits))
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+type inventoryEntry struct {
+	Action string         `json:"action"`
+	Kind   usageKind      `json:"kind"`
+	Owner  string         `json:"owner"`
+	Repo   string         `json:"repo"`
+	Path   string         `json:"path,omitempty"`
+	Refs   []inventoryRef `json:"refs"`
+}
+
+type inventoryRef struct {
+	Ref       string   `json:"ref"`
+	Version   string   `json:"version,omitempty"`
+	Pinned    bool     `json:"pinned"`
+	Count     int      `json:"count"`
+	Locations []string `json:"locations"`
+}
+
+func cmdInventory(args []string) int {
+	files, err := loadWorkflowFiles()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load workflow files: %v\n", err)
+		return 1
+	}
+
+	return runInventory(os.Stdout, files, args)
+}
+
+func runInventory(w io.Writer, files []*WorkflowFile, args []string) int {
+	fs := flag.NewFlagSet("inventory", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	format := fs.String("format", "table", "output format (table, json, csv)")
+
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+
+	if fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "inventory does not accept positional arguments")
+		return 1
+	}
+
+	entries := buildInventory(files)
+
+	var err error
+	switch *format {
+	case "table":
+		if len(entries) == 0 {
+			fmt.Fprintln(w, "No workflow or composite action usages found.")
+			return 0
+		}
+		err = writeInventoryTable(w, entries)
+	case "json":
+		err = writeInventoryJSON(w, entries)
+	case "csv":
+		err = writeInventoryCSV(w, entries)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown format %q (expected table, json, or csv)\n", *format)
+		return 1
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write inventory: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// buildInventory groups usages by repository and sub-path, then by each
+// distinct ref and version comment pairing within that action.
+func buildInventory(files []*WorkflowFile) []*inventoryEntry {
+	entries := make(map[string]*inventoryEntry)
+	refIndex := make(map[string]int)
+	var order []string
+
+	for _, usage := range allUsages(files) {
+		key := usage.Spec.RepoKey()
+		if usage.Spec.Path != "" {
+			key += "/" + strings.ToLower(usage.Spec.Path)
+		}
+		entry, exists := entries[key]
+		if !exists {
+			entry = &inventoryEntry{
+				Action: usage.Spec.FullPath(),
+				Kind:   usage.Kind(),
+				Owner:  usage.Spec.Owner,
+				Repo:   usage.Spec.Repo,
+				Path:   usage.Spec.Path,
+			}
+			entries[key] = entry
+			order = append(order, key)
+		}
+
+		version, _ := splitComment(usage.Comment)
+		refKey := fmt.Sprintf("%s@%s#%s", key, strings.ToLower(usage.Ref), version)
+		idx, exists := refIndex[refKey]
+		if !exists {
+			entry.Refs = append(entry.Refs, inventoryRef{
+				Ref:     usage.Ref,
+				Version: version,
+				Pinned:  isFullCommitSHA(usage.Ref),
+			})
+			idx = len(entry.Refs) - 1
+			refIndex[refKey] = idx
+		}
+		ref := &entry.Refs[idx]
+		ref.Count++
+		ref.Locations = append(ref.Locations, fmt.Sprintf("%s:%d", usage.File.Path, usage.LineNumber()))
+	}
+
+	sort.Strings(order)
+	result := make([]*inventoryEntry, 0, len(order))
+	for _, key := range order {
+		result = append(result, entries[key])
+	}
+	return result
+}
+
+func writeInventoryTable(w io.Writer, entries []*inventoryEntry) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ACTION\tREF\tVERSION\tPINNED\tUSES\tLOCATIONS")
+	for _, entry := range entries {
+		for _, ref := range entry.Refs {
+			version := ref.Version
+			if version == "" {
+				version = "-"
+			}
+			pinned := "no"
+			if ref.Pinned {
+				pinned = "yes"
+			}
+			action := entry.Action
+			if entry.Kind == usageKindReusableWorkflow {
+				action += " (reusable workflow)"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\n",
+				action, ref.Ref, version, pinned, ref.Count, strings.Join(ref.Locations, ", "))
+		}
+	}
+	return tw.Flush()
+}
+
+func writeInventoryJSON(w io.Writer, entries []*inventoryEntry) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(entries)
+}
+
+func writeInventoryCSV(w io.Writer, entries []*inventoryEntry) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"action", "ref", "version", "pinned", "count", "locations"}); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		for _, ref := range entry.Refs {
+			record := []string{
+				entry.Action,
+				ref.Ref,
+				ref.Version,
+				strconv.FormatBool(ref.Pinned),
+				strconv.Itoa(ref.Count),
+				strings.Join(ref.Locations, ";"),
+			}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
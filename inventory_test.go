@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const inventorySHA = "08c6903cd8c0fde910a37f88322edcfb5dd907a8"
+
+func inventoryFixture(t *testing.T) []*WorkflowFile {
+	t.Helper()
+	wf := buildWorkflowFileFromLines(t,
+		`      - uses: actions/checkout@`+inventorySHA+` # v5`,
+		`      - uses: actions/checkout@`+inventorySHA+` # v5`,
+		`      - uses: actions/checkout@v4`,
+		`      - uses: github/codeql-action/init@v3 # v3`,
+		`      - uses: ./local-action`,
+	)
+	return []*WorkflowFile{wf}
+}
+
+func TestBuildInventory(t *testing.T) {
+	t.Parallel()
+	entries := buildInventory(inventoryFixture(t))
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 inventory entries, got %d", len(entries))
+	}
+
+	checkout := entries[0]
+	if checkout.Action != "actions/checkout" {
+		t.Fatalf("unexpected first action %q", checkout.Action)
+	}
+	if len(checkout.Refs) != 2 {
+		t.Fatalf("expected 2 distinct refs for checkout, got %d", len(checkout.Refs))
+	}
+	pinned := checkout.Refs[0]
+	if !pinned.Pinned || pinned.Version != "v5" || pinned.Count != 2 || len(pinned.Locations) != 2 {
+		t.Fatalf("unexpected pinned ref: %+v", pinned)
+	}
+	if !strings.HasSuffix(pinned.Locations[1], ":2") {
+		t.Fatalf("unexpected location %q", pinned.Locations[1])
+	}
+	if unpinned := checkout.Refs[1]; unpinned.Pinned || unpinned.Ref != "v4" || unpinned.Version != "" {
+		t.Fatalf("unexpected unpinned ref: %+v", unpinned)
+	}
+
+	codeql := entries[1]
+	if codeql.Path != "init" || codeql.Action != "github/codeql-action/init" {
+		t.Fatalf("unexpected sub-path entry: %+v", codeql)
+	}
+}
+
+func TestRunInventoryFormats(t *testing.T) {
+	t.Parallel()
+	files := inventoryFixture(t)
+
+	var jsonOut bytes.Buffer
+	if exit := runInventory(&jsonOut, files, []string{"--format", "json"}); exit != 0 {
+		t.Fatalf("runInventory json exit = %d, want 0", exit)
+	}
+	var decoded []inventoryEntry
+	if err := json.Unmarshal(jsonOut.Bytes(), &decoded); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	if len(decoded) != 2 || decoded[0].Refs[0].Count != 2 {
+		t.Fatalf("unexpected JSON inventory: %+v", decoded)
+	}
+
+	var csvOut bytes.Buffer
+	if exit := runInventory(&csvOut, files, []string{"--format", "csv"}); exit != 0 {
+		t.Fatalf("runInventory csv exit = %d, want 0", exit)
+	}
+	records, err := csv.NewReader(&csvOut).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV output: %v", err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("expected header plus 3 rows, got %d", len(records))
+	}
+	if records[1][3] != "true" || records[1][4] != "2" {
+		t.Fatalf("unexpected CSV row: %v", records[1])
+	}
+
+	var tableOut bytes.Buffer
+	if exit := runInventory(&tableOut, files, nil); exit != 0 {
+		t.Fatalf("runInventory table exit = %d, want 0", exit)
+	}
+	if !strings.HasPrefix(tableOut.String(), "ACTION") {
+		t.Fatalf("unexpected table output:\n%s", tableOut.String())
+	}
+
+	if exit := runInventory(&bytes.Buffer{}, files, []string{"--format", "xml"}); exit == 0 {
+		t.Fatal("expected unknown format to fail")
+	}
+}
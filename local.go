@@ -0,0 +1,141 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// repoRelativePath returns a file's path relative to the repository root in
+// slash form, the form LocalUsage.Target uses.
+func repoRelativePath(file *WorkflowFile) string {
+	return strings.TrimPrefix(filepath.ToSlash(file.Path), "./")
+}
+
+// resolveLocalTarget finds the loaded file a ./ reference points at: the
+// workflow file itself, or the action.yml in the referenced directory.
+func resolveLocalTarget(files []*WorkflowFile, target string) *WorkflowFile {
+	dir := target + "/"
+	if target == "." {
+		dir = ""
+	}
+	for _, file := range files {
+		id := repoRelativePath(file)
+		if id == target || id == dir+"action.yml" || id == dir+"action.yaml" {
+			return file
+		}
+	}
+	return nil
+}
+
+// followLocalUsages loads the action.yml of local composite actions that live
+// outside .github/actions, such as ./actions/setup, so their own usages are
+// checked and updated like any other file. Files it loads are followed too.
+func followLocalUsages(files []*WorkflowFile) ([]*WorkflowFile, error) {
+	for i := 0; i < len(files); i++ {
+		for _, local := range files[i].Local {
+			target := local.Target()
+			if local.IsWorkflow() || !local.Valid() || resolveLocalTarget(files, target) != nil {
+				continue
+			}
+			for _, name := range []string{"action.yml", "action.yaml"} {
+				path := filepath.Join(filepath.FromSlash(target), name)
+				content, err := os.ReadFile(path)
+				if errors.Is(err, fs.ErrNotExist) {
+					continue
+				}
+				if err != nil {
+					return nil, err
+				}
+				files = append(files, newWorkflowFile(path, content))
+				break
+			}
+		}
+	}
+	return files, nil
+}
+
+// checkLocalUsages reports local references that GitHub cannot run: paths not
+// starting with ./, composite actions without an action.yml, and reusable
+// workflows that are missing or lack a workflow_call trigger.
+func checkLocalUsages(files []*WorkflowFile, report func(Issue)) {
+	for _, file := range files {
+		for _, local := range file.Local {
+			message := localUsageProblem(files, local)
+			if message == "" {
+				continue
+			}
+			report(Issue{
+				File:    file.Path,
+				Line:    local.LineNumber(),
+				Message: fmt.Sprintf("uses %s, but %s", local.Path, message),
+			})
+		}
+	}
+}
+
+func localUsageProblem(files []*WorkflowFile, local *LocalUsage) string {
+	if !local.Valid() {
+		return "local references must start with ./ and stay inside the repository"
+	}
+
+	target := local.Target()
+	resolved := resolveLocalTarget(files, target)
+	if !local.IsWorkflow() {
+		if resolved == nil {
+			return "no action.yml or action.yaml exists there"
+		}
+		return ""
+	}
+
+	if !strings.HasPrefix(target, ".github/workflows/") || strings.Contains(strings.TrimPrefix(target, ".github/workflows/"), "/") {
+		return "reusable workflows must be in the .github/workflows directory"
+	}
+	if resolved == nil {
+		return "the reusable workflow does not exist"
+	}
+	callable, err := isReusableWorkflow(resolved)
+	if err != nil {
+		return fmt.Sprintf("the reusable workflow could not be parsed: %v", err)
+	}
+	if !callable {
+		return "the workflow is not triggered by workflow_call"
+	}
+	return ""
+}
+
+// isReusableWorkflow reports whether a workflow's on: triggers include
+// workflow_call, in any of the string, list or mapping forms.
+func isReusableWorkflow(file *WorkflowFile) (bool, error) {
+	var workflow struct {
+		On yaml.Node `yaml:"on"`
+	}
+	content := strings.TrimPrefix(string(file.Content()), utf8BOM)
+	if err := yaml.Unmarshal([]byte(content), &workflow); err != nil {
+		return false, err
+	}
+
+	on := workflow.On
+	switch on.Kind {
+	case yaml.ScalarNode:
+		return on.Value == "workflow_call", nil
+	case yaml.SequenceNode:
+		for _, item := range on.Content {
+			if item.Value == "workflow_call" {
+				return true, nil
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i < len(on.Content); i += 2 {
+			if on.Content[i].Value == "workflow_call" {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckLocalUsages(t *testing.T) {
+	t.Parallel()
+
+	files := []*WorkflowFile{
+		newWorkflowFile(".github/workflows/ci.yml", []byte(strings.Join([]string{
+			"jobs:",
+			"  build:",
+			"    steps:",
+			"      - uses: ./.github/actions/setup",
+			"      - uses: ./.github/actions/gone",
+			"      - uses: ../shared/action",
+			"  call:",
+			"    uses: ./.github/workflows/reusable.yml",
+			"  plain:",
+			"    uses: ./.github/workflows/plain.yml",
+			"  missing:",
+			"    uses: ./.github/workflows/missing.yml",
+			"  nested:",
+			"    uses: ./ci/build.yml",
+		}, "\n")+"\n")),
+		newWorkflowFile(".github/workflows/reusable.yml", []byte("on:\n  workflow_call:\n    inputs: {}\njobs: {}\n")),
+		newWorkflowFile(".github/workflows/plain.yml", []byte("on: [push, pull_request]\njobs: {}\n")),
+		newWorkflowFile(".github/actions/setup/action.yml", []byte("runs:\n  using: composite\n  steps: []\n")),
+	}
+
+	var messages []string
+	checkLocalUsages(files, func(issue Issue) {
+		messages = append(messages, fmt.Sprintf("%s %d %s", issue.File, issue.Line, issue.Message))
+	})
+
+	expected := []string{
+		".github/workflows/ci.yml 5 uses ./.github/actions/gone, but no action.yml or action.yaml exists there",
+		".github/workflows/ci.yml 6 uses ../shared/action, but local references must start with ./ and stay inside the repository",
+		".github/workflows/ci.yml 10 uses ./.github/workflows/plain.yml, but the workflow is not triggered by workflow_call",
+		".github/workflows/ci.yml 12 uses ./.github/workflows/missing.yml, but the reusable workflow does not exist",
+		".github/workflows/ci.yml 14 uses ./ci/build.yml, but reusable workflows must be in the .github/workflows directory",
+	}
+	if strings.Join(messages, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("unexpected issues:\n%s\nwant:\n%s", strings.Join(messages, "\n"), strings.Join(expected, "\n"))
+	}
+}
+
+func TestIsReusableWorkflow(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		content  string
+		callable bool
+	}{
+		{"on: workflow_call\n", true},
+		{"on: [push, workflow_call]\n", true},
+		{"on:\n  workflow_call:\n    secrets:\n      token:\n        required: true\n", true},
+		{"on:\n  push:\n    branches: [main]\n", false},
+		{"name: no triggers\n", false},
+	}
+	for _, tc := range cases {
+		callable, err := isReusableWorkflow(newWorkflowFile("workflow.yml", []byte(tc.content)))
+		if err != nil {
+			t.Fatalf("isReusableWorkflow(%q) failed: %v", tc.content, err)
+		}
+		if callable != tc.callable {
+			t.Fatalf("isReusableWorkflow(%q) = %v, want %v", tc.content, callable, tc.callable)
+		}
+	}
+}
+
+func TestLoadWorkflowFilesFollowsLocalActions(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(path, content string) {
+		t.Helper()
+		full := filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+		}
+	}
+	writeFile(".github/workflows/ci.yml", "jobs:\n  build:\n    steps:\n      - uses: ./actions/setup\n")
+	writeFile("actions/setup/action.yml", "runs:\n  using: composite\n  steps:\n    - uses: ./actions/cache\n")
+	writeFile("actions/cache/action.yaml", "runs:\n  using: composite\n  steps:\n    - uses: actions/cache@v4\n")
+	t.Chdir(dir)
+
+	files, err := loadWorkflowFiles()
+	if err != nil {
+		t.Fatalf("loadWorkflowFiles failed: %v", err)
+	}
+	var paths []string
+	for _, file := range files {
+		paths = append(paths, repoRelativePath(file))
+	}
+	expected := ".github/workflows/ci.yml actions/setup/action.yml actions/cache/action.yaml"
+	if strings.Join(paths, " ") != expected {
+		t.Fatalf("expected %s, got %v", expected, paths)
+	}
+	if usages := allUsages(files); len(usages) != 1 || usages[0].Spec.FullPath() != "actions/cache" {
+		t.Fatalf("expected the nested remote usage to be loaded, got %+v", usages)
+	}
+}
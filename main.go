@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/cli/go-gh/v2/pkg/api"
@@ -40,6 +41,33 @@ func main() {
 	case "update":
 		exit := cmdUpdate(args)
 		os.Exit(exit)
+	case "inventory", "list":
+		exit := cmdInventory(args)
+		os.Exit(exit)
+	case "outdated":
+		exit := cmdOutdated(args)
+		os.Exit(exit)
+	case "changes":
+		exit := cmdChanges(args)
+		os.Exit(exit)
+	case "reformat":
+		exit := cmdReformat(args)
+		os.Exit(exit)
+	case "runtimes":
+		exit := cmdRuntimes(args)
+		os.Exit(exit)
+	case "audit":
+		exit := cmdAudit(args)
+		os.Exit(exit)
+	case "sbom":
+		exit := cmdSBOM(args)
+		os.Exit(exit)
+	case "graph":
+		exit := cmdGraph(args)
+		os.Exit(exit)
+	case "why":
+		exit := cmdWhy(args)
+		os.Exit(exit)
 	case "--help", "-h", "help":
 		printHelp()
 		os.Exit(0)
@@ -78,11 +106,6 @@ func cmdVerify(args []string) int {
 }
 
 func cmdFix(args []string) int {
-	if len(args) > 0 {
-		fmt.Fprintf(os.Stderr, "fix does not accept additional arguments\n")
-		return 1
-	}
-
 	files, err := loadWorkflowFiles()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "failed to load workflow files: %v\n", err)
@@ -100,7 +123,7 @@ func cmdFix(args []string) int {
 		return 1
 	}
 
-	exit := runFix(client, files)
+	exit := runFix(client, files, args)
 	return exit
 }
 
@@ -156,31 +179,156 @@ Commands:
   fix               Pin actions to commit SHAs based on their tagged versions.
   upgrade [repo]    Upgrade one action (owner/repo) or all actions to the latest release.
   update [repo]     Refresh pinned commits to the latest release that matches current version spec.
+  inventory         List every referenced action with its refs, version comments, and locations (alias: list).
+  outdated [repo]   Report available updates and upgrades without modifying files.
+  changes <repo>    Diff action.yml inputs, outputs, and runtime between pinned and latest versions.
+  reformat          Rewrite every version comment in one style without changing refs.
+  runtimes          Flag actions whose pinned action.yml uses a deprecated Node.js runtime.
+  audit             Report usages affected by security advisories for GitHub Actions.
+  sbom              Print a CycloneDX or SPDX bill of materials of referenced actions and images.
+  graph             Export workflow, local composite action, and action dependencies as a graph.
+  why <repo>        Show every use of an action and the local actions and reusable workflows leading to it.
 
 Upgrade flags:
   --all             Upgrade every referenced action to its latest release tag.
   --version <tag>   Upgrade to a specific release tag (only with a single repo argument).
+  --level <level>   Only allow patch, minor, or major bumps from the current version comment.
+  --notes           Print a markdown changelog of the releases being picked up.
 
 Update flags:
-  --all             Update every referenced action to match its existing version spec.`)
+  --all             Update every referenced action to match its existing version spec.
+  --notes           Print a markdown changelog of the releases being picked up.
+
+Fix flags:
+  --canonical       Rewrite actions from renamed or transferred repositories to the new owner/name.
+
+Fix and update flags:
+  --exact           Record the resolved exact tag after floating comments, e.g. # v4 (v4.2.1).
+
+Fix, upgrade, update, and reformat flags:
+  --comment-style   Write version comments as plain, tag, pin, or renovate.
+  --commit          Stage the changed files and create a local git commit.
+  --create-pr       Commit the changes to a new branch and open a pull request.
+  --base <branch>   Base branch for --create-pr (defaults to the repository's default branch).
+  --group-by <how>  Split --create-pr changes: all (default, one PR), level, or action.
+
+Inventory flags:
+  --format <fmt>    Output format: table (default), json, or csv.
+
+Outdated flags:
+  --format <fmt>    Output format: table (default) or json.
+  --exit-code       Exit with status 1 when any action is outdated (useful in CI).
+  --notes           Print a markdown changelog of the releases up to the latest release.
+
+Runtimes flags:
+  --format <fmt>    Output format: table (default) or json.
+  --exit-code       Exit with status 1 when any action uses a deprecated runtime.
+
+Audit flags:
+  --format <fmt>    Output format: table (default) or json.
+  --advisories <f>  Read advisories from a local JSON file instead of the GitHub Advisory Database.
+  --fix             Upgrade vulnerable usages to the first patched version (accepts --commit, --create-pr).
+
+SBOM flags:
+  --format <fmt>    Document format: cyclonedx (default) or spdx.
+  --name <name>     Name of the described project (defaults to the current directory).
+
+Graph flags:
+  --format <fmt>    Output format: dot (default), mermaid, or json.
+
+Why flags:
+  --format <fmt>    Output format: table (default) or json.
+
+Changes flags:
+  --version <tag>   Compare against a specific release tag instead of the latest release.`)
 }
 
 type WorkflowFile struct {
-	Path    string
-	Lines   []string
-	Uses    []*ActionUsage
+	Path  string
+	Lines []string
+	Uses  []*ActionUsage
+	// Local lists the ./ references to composite actions and reusable
+	// workflows in the same repository, which are never pinned.
+	Local   []*LocalUsage
 	changed bool
+	changes []*usageChange
+
+	// Formatting of the file on disk, preserved when it is written back.
+	bom            bool
+	lineEnding     string
+	noFinalNewline bool
+}
+
+// usageChange records a rewrite of a single uses line so callers can report
+// or replay it after the fact.
+type usageChange struct {
+	Usage      *ActionUsage
+	OldLine    string
+	NewLine    string
+	OldRef     string
+	OldComment string
+}
+
+func (wf *WorkflowFile) Content() []byte {
+	ending := wf.lineEnding
+	if ending == "" {
+		ending = "\n"
+	}
+	content := strings.Join(wf.Lines, ending)
+	if !wf.noFinalNewline && !strings.HasSuffix(content, ending) {
+		content += ending
+	}
+	if wf.bom {
+		content = utf8BOM + content
+	}
+	return []byte(content)
+}
+
+// withLines returns a copy of the file with different content but the same
+// path and on-disk formatting.
+func (wf *WorkflowFile) withLines(lines []string) *WorkflowFile {
+	return &WorkflowFile{
+		Path:           wf.Path,
+		Lines:          lines,
+		Uses:           wf.Uses,
+		Local:          wf.Local,
+		bom:            wf.bom,
+		lineEnding:     wf.lineEnding,
+		noFinalNewline: wf.noFinalNewline,
+	}
 }
 
+// Save writes the file atomically via a temporary file in the same directory,
+// keeping the existing file's permissions.
 func (wf *WorkflowFile) Save() error {
 	if !wf.changed {
 		return nil
 	}
-	content := strings.Join(wf.Lines, "\n")
-	if !strings.HasSuffix(content, "\n") {
-		content += "\n"
+
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(wf.Path); err == nil {
+		mode = info.Mode().Perm()
 	}
-	return os.WriteFile(wf.Path, []byte(content), 0o644)
+
+	tmp, err := os.CreateTemp(filepath.Dir(wf.Path), "."+filepath.Base(wf.Path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := tmp.Write(wf.Content()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, wf.Path)
 }
 
 type ActionSpec struct {
@@ -201,46 +349,198 @@ func (s ActionSpec) FullPath() string {
 	return base
 }
 
+// IsReusableWorkflow reports whether the spec names a reusable workflow file,
+// as in owner/repo/.github/workflows/build.yml, rather than an action.
+func (s ActionSpec) IsReusableWorkflow() bool {
+	return strings.HasPrefix(s.Path, ".github/workflows/") &&
+		(strings.HasSuffix(s.Path, ".yml") || strings.HasSuffix(s.Path, ".yaml"))
+}
+
+// Describe names the spec for messages, calling out reusable workflows.
+func (s ActionSpec) Describe() string {
+	if s.IsReusableWorkflow() {
+		return "reusable workflow " + s.FullPath()
+	}
+	return s.FullPath()
+}
+
+// usageKind distinguishes remote actions from remote reusable workflows.
+type usageKind string
+
+const (
+	usageKindAction           usageKind = "action"
+	usageKindReusableWorkflow usageKind = "reusable-workflow"
+)
+
 type ActionUsage struct {
-	File       *WorkflowFile
-	Line       int
-	Indent     string
-	Separator  string
-	Quoted     bool
-	Spec       ActionSpec
-	Ref        string
+	File      *WorkflowFile
+	Line      int
+	Indent    string
+	Separator string
+	// Quote is the quote character wrapping the value, if any.
+	Quote string
+	Spec  ActionSpec
+	Ref   string
+	// Comment is the trimmed comment text; RawComment is everything after the
+	// '#' exactly as written.
 	Comment    string
 	RawComment string
+	// CommentPrefix is the text between the value and the comment text, such
+	// as " # ", and Trailing is any whitespace left at the end of the line.
+	CommentPrefix string
+	Trailing      string
 }
 
 func (u *ActionUsage) LineNumber() int {
 	return u.Line + 1
 }
 
+func (u *ActionUsage) Kind() usageKind {
+	if u.Spec.IsReusableWorkflow() {
+		return usageKindReusableWorkflow
+	}
+	return usageKindAction
+}
+
+// LocalUsage is a uses line referencing a path in the same repository, such
+// as ./.github/actions/setup.
+type LocalUsage struct {
+	File *WorkflowFile
+	Line int
+	Path string
+}
+
+func (u *LocalUsage) LineNumber() int {
+	return u.Line + 1
+}
+
+// Target returns the referenced path relative to the repository root, in
+// slash form and without the leading "./".
+func (u *LocalUsage) Target() string {
+	return filepath.ToSlash(filepath.Clean(strings.TrimPrefix(u.Path, "./")))
+}
+
+// Valid reports whether the reference has the ./ form GitHub accepts and
+// stays inside the repository.
+func (u *LocalUsage) Valid() bool {
+	target := u.Target()
+	return strings.HasPrefix(u.Path, "./") && target != ".." && !strings.HasPrefix(target, "../")
+}
+
+// IsWorkflow reports whether the reference names a reusable workflow file
+// rather than a composite action directory.
+func (u *LocalUsage) IsWorkflow() bool {
+	return strings.HasSuffix(u.Path, ".yml") || strings.HasSuffix(u.Path, ".yaml")
+}
+
+// Set rewrites the usage's line to point at ref with the given comment.
+// Only commit SHAs are normalized to lowercase; the original quoting, comment
+// spacing and trailing whitespace are kept.
 func (u *ActionUsage) Set(ref, comment string) {
-	value := fmt.Sprintf("%s@%s", u.Spec.FullPath(), strings.ToLower(ref))
-	if u.Quoted {
-		value = fmt.Sprintf("%q", value)
+	if isFullCommitSHA(ref) {
+		ref = strings.ToLower(ref)
 	}
+	value := fmt.Sprintf("%s%s@%s%s", u.Quote, u.Spec.FullPath(), ref, u.Quote)
 	sep := u.Separator
 	if sep == "" {
 		sep = " "
 	}
 	line := fmt.Sprintf("%suses:%s%s", u.Indent, sep, value)
+
+	rawComment := u.RawComment
+	if comment != u.Comment {
+		lead := rawComment[:len(rawComment)-len(strings.TrimLeft(rawComment, " \t"))]
+		if u.CommentPrefix == "" {
+			lead = " "
+		}
+		rawComment = lead + comment
+	}
+	prefix := u.CommentPrefix
 	if comment != "" {
-		line = fmt.Sprintf("%s # %s", line, comment)
+		if prefix == "" {
+			prefix = " #"
+		}
+		line += prefix + rawComment
+	} else {
+		prefix = ""
+		rawComment = ""
 	}
+	line += u.Trailing
+
+	u.File.recordChange(u, line)
 	u.File.Lines[u.Line] = line
 	u.File.changed = true
-	u.Ref = strings.ToLower(ref)
+	u.Ref = ref
 	u.Comment = comment
-	u.RawComment = comment
+	u.RawComment = rawComment
+	u.CommentPrefix = prefix
+}
+
+// CommentWithVersion returns the usage's comment with its version replaced,
+// leaving the rest of the comment byte-for-byte intact. A non-empty exact tag
+// that differs from version is recorded after it, as in "v4 (v4.2.1)";
+// otherwise any recorded exact tag is dropped. An empty style keeps the
+// comment's existing style.
+func (u *ActionUsage) CommentWithVersion(version, exact string, style commentStyle) string {
+	// Comments that leave out the tag family record the exact tag without it
+	// too, as in "v1 (v1.3.0)" for setup-foo/v1.3.0.
+	if prefix, _ := splitTagPrefix(version); prefix == "" {
+		_, exact = splitTagPrefix(exact)
+	}
+	if strings.EqualFold(ensureLeadingV(exact), ensureLeadingV(version)) {
+		exact = ""
+	}
+	suffix := ""
+	if exact != "" {
+		suffix = " (" + exact + ")"
+	}
+
+	current := parseVersionComment(u.Comment)
+	if current.Version == "" {
+		if style == "" {
+			style = commentStylePlain
+		}
+		return joinComment(formatVersionComment(style, version)+suffix, u.Comment)
+	}
+
+	var head string
+	if style == "" || style == current.Style {
+		start := current.End - len(current.Version)
+		head = u.Comment[:start] + version
+	} else {
+		head = formatVersionComment(style, version)
+	}
+	if exact != "" && current.Exact != "" {
+		open := current.ExactEnd - len(current.Exact) - len("()")
+		suffix = u.Comment[current.End:open] + "(" + exact + ")"
+	}
+	return head + suffix + u.Comment[current.ExactEnd:]
+}
+
+func (wf *WorkflowFile) recordChange(u *ActionUsage, line string) {
+	for _, change := range wf.changes {
+		if change.Usage == u {
+			change.NewLine = line
+			return
+		}
+	}
+	wf.changes = append(wf.changes, &usageChange{
+		Usage:      u,
+		OldLine:    wf.Lines[u.Line],
+		NewLine:    line,
+		OldRef:     u.Ref,
+		OldComment: u.Comment,
+	})
 }
 
 type TagResolver struct {
-	client restClient
-	cache  map[string]string
-	spec   map[string]specResolution
+	client       restClient
+	cache        map[string]string
+	spec         map[string]specResolution
+	releases     map[string][]string
+	commitTags   map[string]map[string][]string
+	repositories map[string]repositoryResult
+	prefixes     map[string]string
 }
 
 type specResolution struct {
@@ -250,9 +550,13 @@ type specResolution struct {
 
 func NewTagResolver(client restClient) *TagResolver {
 	return &TagResolver{
-		client: client,
-		cache:  make(map[string]string),
-		spec:   make(map[string]specResolution),
+		client:       client,
+		cache:        make(map[string]string),
+		spec:         make(map[string]specResolution),
+		releases:     make(map[string][]string),
+		commitTags:   make(map[string]map[string][]string),
+		repositories: make(map[string]repositoryResult),
+		prefixes:     make(map[string]string),
 	}
 }
 
@@ -352,7 +656,8 @@ func (r *TagResolver) resolveExactSpec(owner, repo, original, normalized string)
 		candidates = append(candidates, original)
 	}
 
-	if trimmed := strings.TrimPrefix(strings.ToLower(original), "v"); trimmed != original {
+	prefix, version := splitTagPrefix(original)
+	if trimmed := prefix + strings.TrimPrefix(strings.ToLower(version), "v"); trimmed != original {
 		candidates = append(candidates, trimmed)
 	}
 
@@ -435,6 +740,177 @@ func (r *TagResolver) findLatestMatchingTag(owner, repo, normalized string, kind
 	return "", fmt.Errorf("no release found matching %s for %s/%s", normalized, owner, repo)
 }
 
+// ReleaseTags lists the tag names of every non-prerelease release, newest
+// first. Repositories without releases fall back to their tags.
+func (r *TagResolver) ReleaseTags(owner, repo string) ([]string, error) {
+	cacheKey := fmt.Sprintf("%s/%s", strings.ToLower(owner), strings.ToLower(repo))
+	if cached, ok := r.releases[cacheKey]; ok {
+		return cached, nil
+	}
+
+	var names []string
+	for page := 1; ; page++ {
+		var releases []struct {
+			TagName    string `json:"tag_name"`
+			Prerelease bool   `json:"prerelease"`
+		}
+		path := fmt.Sprintf("repos/%s/%s/releases?per_page=%d&page=%d", owner, repo, listPageSize, page)
+		if err := r.client.Get(path, &releases); err != nil {
+			var httpErr *api.HTTPError
+			if errors.As(err, &httpErr) && httpErr.StatusCode == 404 {
+				break
+			}
+			return nil, err
+		}
+		for _, release := range releases {
+			if !release.Prerelease {
+				names = append(names, release.TagName)
+			}
+		}
+		if len(releases) < listPageSize {
+			break
+		}
+	}
+
+	if len(names) == 0 {
+		for page := 1; ; page++ {
+			var tags []struct {
+				Name string `json:"name"`
+			}
+			path := fmt.Sprintf("repos/%s/%s/tags?per_page=%d&page=%d", owner, repo, listPageSize, page)
+			if err := r.client.Get(path, &tags); err != nil {
+				return nil, err
+			}
+			for _, tag := range tags {
+				names = append(names, tag.Name)
+			}
+			if len(tags) < listPageSize {
+				break
+			}
+		}
+	}
+
+	r.releases[cacheKey] = names
+	return names, nil
+}
+
+// ResolveRef resolves any ref the commits API accepts, including branches,
+// to a commit SHA.
+func (r *TagResolver) ResolveRef(owner, repo, reference string) (string, error) {
+	if isFullCommitSHA(reference) {
+		return strings.ToLower(reference), nil
+	}
+	cacheKey := fmt.Sprintf("%s/%s@@%s", strings.ToLower(owner), strings.ToLower(repo), reference)
+	if sha, ok := r.cache[cacheKey]; ok {
+		return sha, nil
+	}
+	var commit struct {
+		SHA string `json:"sha"`
+	}
+	if err := r.client.Get(fmt.Sprintf("repos/%s/%s/commits/%s", owner, repo, url.PathEscape(reference)), &commit); err != nil {
+		return "", err
+	}
+	lowered := strings.ToLower(commit.SHA)
+	r.cache[cacheKey] = lowered
+	return lowered, nil
+}
+
+// repositoryInfo is the subset of repository metadata used to spot archived
+// and renamed action repositories.
+type repositoryInfo struct {
+	FullName      string `json:"full_name"`
+	Archived      bool   `json:"archived"`
+	DefaultBranch string `json:"default_branch"`
+}
+
+type repositoryResult struct {
+	info *repositoryInfo
+	err  error
+}
+
+// Repository fetches repository metadata. The API follows redirects for
+// renamed and transferred repositories, so FullName is the canonical name.
+func (r *TagResolver) Repository(owner, repo string) (*repositoryInfo, error) {
+	cacheKey := fmt.Sprintf("%s/%s", strings.ToLower(owner), strings.ToLower(repo))
+	if cached, ok := r.repositories[cacheKey]; ok {
+		return cached.info, cached.err
+	}
+	var info repositoryInfo
+	err := r.client.Get(fmt.Sprintf("repos/%s/%s", owner, repo), &info)
+	result := repositoryResult{err: err}
+	if err == nil {
+		result.info = &info
+	}
+	r.repositories[cacheKey] = result
+	return result.info, result.err
+}
+
+// Renamed returns the canonical owner/repo when it differs from the name
+// used to look the repository up.
+func (info *repositoryInfo) Renamed(owner, repo string) (string, bool) {
+	if info.FullName == "" || strings.EqualFold(info.FullName, owner+"/"+repo) {
+		return "", false
+	}
+	return info.FullName, true
+}
+
+// TagForCommit reverse-resolves a commit SHA to the most specific semantic
+// version tag pointing at it, such as v3.5.2 over v3.5 or v3. It returns an
+// empty string when no tag points at the commit.
+func (r *TagResolver) TagForCommit(owner, repo, sha string) (string, error) {
+	cacheKey := fmt.Sprintf("%s/%s", strings.ToLower(owner), strings.ToLower(repo))
+	byCommit, ok := r.commitTags[cacheKey]
+	if !ok {
+		byCommit = make(map[string][]string)
+		for page := 1; ; page++ {
+			var tags []struct {
+				Name   string `json:"name"`
+				Commit struct {
+					SHA string `json:"sha"`
+				} `json:"commit"`
+			}
+			path := fmt.Sprintf("repos/%s/%s/tags?per_page=%d&page=%d", owner, repo, listPageSize, page)
+			if err := r.client.Get(path, &tags); err != nil {
+				return "", err
+			}
+			for _, tag := range tags {
+				commit := strings.ToLower(tag.Commit.SHA)
+				byCommit[commit] = append(byCommit[commit], tag.Name)
+			}
+			if len(tags) < listPageSize {
+				break
+			}
+		}
+		r.commitTags[cacheKey] = byCommit
+	}
+
+	best := ""
+	bestRank := -1
+	var bestVersion semver
+	for _, name := range byCommit[strings.ToLower(sha)] {
+		kind, _ := classifyVersionSpec(name)
+		rank := tagSpecificity(kind)
+		version, _ := parseTagVersion(name)
+		if rank > bestRank || (rank == bestRank && version.Compare(bestVersion) > 0) {
+			best, bestRank, bestVersion = name, rank, version
+		}
+	}
+	return best, nil
+}
+
+func tagSpecificity(kind versionSpecKind) int {
+	switch kind {
+	case specExact:
+		return 3
+	case specMinor:
+		return 2
+	case specMajor:
+		return 1
+	default:
+		return 0
+	}
+}
+
 const listPageSize = 100
 
 type versionSpecKind int
@@ -453,20 +929,23 @@ var (
 	semverMajorRE = regexp.MustCompile(`^[vV]?\d+$`)
 )
 
+// classifyVersionSpec reports how specific a version spec is and normalizes
+// it. Path-scoped specs such as setup-foo/v1 keep their prefix.
 func classifyVersionSpec(spec string) (versionSpecKind, string) {
 	spec = strings.TrimSpace(spec)
 	if spec == "" {
 		return specUnknown, ""
 	}
 
-	lower := strings.ToLower(spec)
+	prefix, version := splitTagPrefix(spec)
+	lower := strings.ToLower(version)
 	switch {
 	case semverExactRE.MatchString(lower):
-		return specExact, ensureLeadingV(lower)
+		return specExact, prefix + ensureLeadingV(lower)
 	case semverMinorRE.MatchString(lower):
-		return specMinor, ensureLeadingV(lower)
+		return specMinor, prefix + ensureLeadingV(lower)
 	case semverMajorRE.MatchString(lower):
-		return specMajor, ensureLeadingV(lower)
+		return specMajor, prefix + ensureLeadingV(lower)
 	default:
 		return specUnknown, spec
 	}
@@ -485,11 +964,23 @@ func ensureLeadingV(spec string) string {
 	return spec
 }
 
+// matchVersionSpec reports whether a tag satisfies a normalized spec. Tags
+// only match specs from the same tag family, so setup-foo/v1 never matches
+// v1.2.0 or other-action/v1.2.0.
 func matchVersionSpec(tag, normalized string, kind versionSpecKind) bool {
 	tagLower := strings.ToLower(tag)
 	normalizedLower := strings.ToLower(normalized)
-	tagTrimmed := strings.TrimPrefix(tagLower, "v")
-	specTrimmed := strings.TrimPrefix(normalizedLower, "v")
+	if kind == specUnknown {
+		return tagLower == normalizedLower
+	}
+
+	tagPrefix, tagVersion := splitTagPrefix(tagLower)
+	specPrefix, specVersion := splitTagPrefix(normalizedLower)
+	if tagPrefix != specPrefix {
+		return false
+	}
+	tagTrimmed := strings.TrimPrefix(tagVersion, "v")
+	specTrimmed := strings.TrimPrefix(specVersion, "v")
 
 	switch kind {
 	case specExact:
@@ -504,44 +995,224 @@ func matchVersionSpec(tag, normalized string, kind versionSpecKind) bool {
 	}
 }
 
+type semver struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+}
+
+var semverRE = regexp.MustCompile(`^[vV]?(\d+)(?:\.(\d+))?(?:\.(\d+))?(?:-([0-9A-Za-z\.-]+))?(?:\+[0-9A-Za-z\.-]+)?$`)
+
+// parseSemver parses full and partial version tags such as v1, v1.2 and
+// v1.2.3-beta.1. Missing minor and patch components are treated as zero.
+func parseSemver(tag string) (semver, bool) {
+	match := semverRE.FindStringSubmatch(strings.TrimSpace(tag))
+	if match == nil {
+		return semver{}, false
+	}
+	var v semver
+	v.Major, _ = strconv.Atoi(match[1])
+	if match[2] != "" {
+		v.Minor, _ = strconv.Atoi(match[2])
+	}
+	if match[3] != "" {
+		v.Patch, _ = strconv.Atoi(match[3])
+	}
+	v.Prerelease = match[4]
+	return v, true
+}
+
+func (v semver) Compare(other semver) int {
+	switch {
+	case v.Major != other.Major:
+		return compareInts(v.Major, other.Major)
+	case v.Minor != other.Minor:
+		return compareInts(v.Minor, other.Minor)
+	case v.Patch != other.Patch:
+		return compareInts(v.Patch, other.Patch)
+	case v.Prerelease == other.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case other.Prerelease == "":
+		return -1
+	default:
+		return strings.Compare(v.Prerelease, other.Prerelease)
+	}
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// isMajorBump reports whether moving from one tag to another crosses a major
+// version boundary. Tags that are not semantic versions never count.
+func isMajorBump(from, to string) bool {
+	fromVersion, ok := parseTagVersion(from)
+	if !ok {
+		return false
+	}
+	toVersion, ok := parseTagVersion(to)
+	if !ok {
+		return false
+	}
+	return toVersion.Major > fromVersion.Major
+}
+
+type issueSeverity int
+
+const (
+	severityError issueSeverity = iota
+	severityWarning
+)
+
+// issueKind separates policy violations from pinning problems in reports.
+type issueKind string
+
+const (
+	issueKindPinning issueKind = ""
+	issueKindPolicy  issueKind = "policy"
+)
+
 type Issue struct {
-	File    string
-	Line    int
-	Message string
+	File     string
+	Line     int
+	Message  string
+	Severity issueSeverity
+	Kind     issueKind
 }
 
 func runVerify(client restClient, files []*WorkflowFile) int {
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load configuration: %v\n", err)
+		return 1
+	}
+	return verifyFiles(client, files, cfg)
+}
+
+// verifyFiles checks every usage against its version comment and the
+// configured policy.
+func verifyFiles(client restClient, files []*WorkflowFile, cfg *Config) int {
 	resolver := NewTagResolver(client)
+	classifier := newProvenanceClassifier(client, resolver)
+	workflows := newReusableWorkflowChecker(client, cfg.Policy)
 	var issues []Issue
 
+	// classify warns when a pinned commit is not exactly a tag on the
+	// default branch.
+	classify := func(usage *ActionUsage) {
+		provenance, err := classifier.Classify(usage.Spec.Owner, usage.Spec.Repo, usage.Ref)
+		message := ""
+		switch {
+		case err != nil:
+			message = fmt.Sprintf("unable to classify pinned SHA %s for %s: %v", usage.Ref, usage.Spec.Describe(), err)
+		case !provenance.Released():
+			message = fmt.Sprintf("pinned SHA %s for %s %s", usage.Ref, usage.Spec.Describe(), provenance.Describe())
+		default:
+			return
+		}
+		issues = append(issues, Issue{
+			File:     usage.File.Path,
+			Line:     usage.LineNumber(),
+			Message:  message,
+			Severity: severityWarning,
+		})
+	}
+
+	for _, usage := range allUsages(files) {
+		if violation := cfg.Policy.Violation(usage); violation != "" {
+			issues = append(issues, Issue{
+				File:    usage.File.Path,
+				Line:    usage.LineNumber(),
+				Message: fmt.Sprintf("uses %s@%s %s", usage.Spec.Describe(), refLabel(usage.Ref), violation),
+				Kind:    issueKindPolicy,
+			})
+		}
+	}
+
+	checkLocalUsages(files, func(issue Issue) {
+		issues = append(issues, issue)
+	})
+
+	missing := checkRepositories(resolver, files, func(issue Issue) {
+		issues = append(issues, issue)
+	})
+
 	for _, file := range files {
 		for _, usage := range file.Uses {
+			if missing[usage.Spec.RepoKey()] {
+				continue
+			}
 			ref := usage.Ref
 			if !isFullCommitSHA(ref) {
+				if cfg.Policy.Trusted(usage.Spec) {
+					if cfg.Policy.CheckTrustedRefs {
+						if _, err := resolver.ResolveRef(usage.Spec.Owner, usage.Spec.Repo, ref); err != nil {
+							issues = append(issues, Issue{
+								File:    file.Path,
+								Line:    usage.LineNumber(),
+								Message: fmt.Sprintf("uses %s@%s from a trusted owner, but the ref could not be resolved: %v", usage.Spec.Describe(), ref, err),
+							})
+						}
+					}
+					continue
+				}
 				issues = append(issues, Issue{
 					File:    file.Path,
 					Line:    usage.LineNumber(),
-					Message: fmt.Sprintf("uses %s is not pinned to a full commit SHA (%s)", usage.Spec.FullPath(), ref),
+					Message: fmt.Sprintf("uses %s is not pinned to a full commit SHA (%s)", usage.Spec.Describe(), ref),
 				})
 				continue
 			}
 
+			if usage.Kind() == usageKindReusableWorkflow {
+				issues = append(issues, workflows.Check(usage)...)
+			}
+			classify(usage)
+
 			version, _ := splitComment(usage.Comment)
 			if version == "" {
+				message := fmt.Sprintf("uses %s is missing a version comment", usage.Spec.Describe())
+				if tag, err := resolver.TagForCommit(usage.Spec.Owner, usage.Spec.Repo, ref); err == nil {
+					if tag != "" {
+						message += fmt.Sprintf(" (pinned commit is %s; run fix to annotate it)", tag)
+					} else {
+						message += " (no tag points at the pinned commit)"
+					}
+				}
 				issues = append(issues, Issue{
 					File:    file.Path,
 					Line:    usage.LineNumber(),
-					Message: fmt.Sprintf("uses %s is missing a version comment", usage.Spec.FullPath()),
+					Message: message,
 				})
 				continue
 			}
 
-			tag, commit, err := resolver.ResolveSpec(usage.Spec.Owner, usage.Spec.Repo, version)
+			prefix, err := resolver.TagPrefix(usage.Spec, cfg.TagPrefixes)
 			if err != nil {
 				issues = append(issues, Issue{
 					File:    file.Path,
 					Line:    usage.LineNumber(),
-					Message: fmt.Sprintf("failed to resolve %s spec %s: %v", usage.Spec.FullPath(), version, err),
+					Message: fmt.Sprintf("failed to determine the tag prefix of %s: %v", usage.Spec.Describe(), err),
+				})
+				continue
+			}
+
+			tag, commit, err := resolver.ResolveSpec(usage.Spec.Owner, usage.Spec.Repo, withTagPrefix(version, prefix))
+			if err != nil {
+				issues = append(issues, Issue{
+					File:    file.Path,
+					Line:    usage.LineNumber(),
+					Message: fmt.Sprintf("failed to resolve %s spec %s: %v", usage.Spec.Describe(), version, err),
 				})
 				continue
 			}
@@ -551,7 +1222,29 @@ func runVerify(client restClient, files []*WorkflowFile) int {
 					File: file.Path,
 					Line: usage.LineNumber(),
 					Message: fmt.Sprintf("pinned SHA %s does not match %s (%s) for %s spec %s",
-						ref, tag, commit, usage.Spec.FullPath(), version),
+						ref, tag, commit, usage.Spec.Describe(), version),
+				})
+			}
+
+			exact := parseVersionComment(usage.Comment).Exact
+			if exact == "" {
+				continue
+			}
+			_, exactCommit, err := resolver.ResolveSpec(usage.Spec.Owner, usage.Spec.Repo, withTagPrefix(exact, prefix))
+			if err != nil {
+				issues = append(issues, Issue{
+					File:    file.Path,
+					Line:    usage.LineNumber(),
+					Message: fmt.Sprintf("failed to resolve %s exact tag %s: %v", usage.Spec.Describe(), exact, err),
+				})
+				continue
+			}
+			if !strings.EqualFold(exactCommit, ref) {
+				issues = append(issues, Issue{
+					File: file.Path,
+					Line: usage.LineNumber(),
+					Message: fmt.Sprintf("pinned SHA %s does not match exact tag %s (%s) for %s",
+						ref, exact, exactCommit, usage.Spec.Describe()),
 				})
 			}
 		}
@@ -564,17 +1257,126 @@ func runVerify(client restClient, files []*WorkflowFile) int {
 			}
 			return issues[i].File < issues[j].File
 		})
+		failed := false
 		for _, issue := range issues {
-			fmt.Printf("%s:%d %s\n", issue.File, issue.Line, issue.Message)
+			label := ""
+			if issue.Kind != issueKindPinning {
+				label = string(issue.Kind) + ": "
+			}
+			if issue.Severity == severityWarning {
+				label = "warning: " + label
+			} else {
+				failed = true
+			}
+			fmt.Printf("%s:%d %s%s\n", issue.File, issue.Line, label, issue.Message)
+		}
+		if failed {
+			return 1
 		}
-		return 1
 	}
 
 	fmt.Println("All workflows and composite actions are pinned to matching commit SHAs.")
 	return 0
 }
 
-func runFix(client restClient, files []*WorkflowFile) int {
+// checkRepositories looks up every distinct action repository once and
+// reports, at its first usage, repositories that are archived, have moved to a
+// new owner or name, or no longer exist. It returns the set of missing
+// repositories, keyed by RepoKey, so their usages can be skipped.
+func checkRepositories(resolver *TagResolver, files []*WorkflowFile, report func(Issue)) map[string]bool {
+	missing := make(map[string]bool)
+	seen := make(map[string]bool)
+	for _, usage := range allUsages(files) {
+		key := usage.Spec.RepoKey()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		name := fmt.Sprintf("%s/%s", usage.Spec.Owner, usage.Spec.Repo)
+		issue := Issue{File: usage.File.Path, Line: usage.LineNumber(), Severity: severityWarning}
+		info, err := resolver.Repository(usage.Spec.Owner, usage.Spec.Repo)
+		if err != nil {
+			var httpErr *api.HTTPError
+			if errors.As(err, &httpErr) && httpErr.StatusCode == 404 {
+				missing[key] = true
+				issue.Severity = severityError
+				issue.Message = fmt.Sprintf("repository %s does not exist or is not accessible", name)
+			} else {
+				issue.Message = fmt.Sprintf("unable to look up repository %s: %v", name, err)
+			}
+			report(issue)
+			continue
+		}
+		if info.Archived {
+			issue.Message = fmt.Sprintf("repository %s is archived", name)
+			report(issue)
+		}
+		if canonical, ok := info.Renamed(usage.Spec.Owner, usage.Spec.Repo); ok {
+			issue.Message = fmt.Sprintf("repository %s has moved to %s (run fix --canonical to update)", name, canonical)
+			report(issue)
+		}
+	}
+	return missing
+}
+
+// canonicalizeUsage rewrites a usage of a renamed or transferred repository
+// to its canonical owner/name, keeping its ref, path and comment.
+func canonicalizeUsage(resolver *TagResolver, usage *ActionUsage) error {
+	info, err := resolver.Repository(usage.Spec.Owner, usage.Spec.Repo)
+	if err != nil {
+		return err
+	}
+	name, ok := info.Renamed(usage.Spec.Owner, usage.Spec.Repo)
+	if !ok {
+		return nil
+	}
+	if owner, repo, found := strings.Cut(name, "/"); found {
+		usage.Spec.Owner = owner
+		usage.Spec.Repo = repo
+		usage.Set(usage.Ref, usage.Comment)
+	}
+	return nil
+}
+
+func runFix(client restClient, files []*WorkflowFile, args []string) int {
+	fs := flag.NewFlagSet("fix", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	exact := fs.Bool("exact", false, "record the resolved exact tag after floating version comments")
+	canonical := fs.Bool("canonical", false, "rewrite actions whose repository was renamed or transferred to the new owner/name")
+
+	styleFlag := commentStyleFlag(fs)
+
+	var publish publishOptions
+	publish.register(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+
+	if fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "fix does not accept positional arguments")
+		return 1
+	}
+
+	style, err := effectiveCommentStyle(*styleFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load configuration: %v\n", err)
+		return 1
+	}
+
+	if err := publish.validate(files); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
 	resolver := NewTagResolver(client)
 	var warnings []string
 	var updated int
@@ -582,33 +1384,65 @@ func runFix(client restClient, files []*WorkflowFile) int {
 
 	for _, file := range files {
 		for _, usage := range file.Uses {
+			if *canonical {
+				if err := canonicalizeUsage(resolver, usage); err != nil {
+					warnings = append(warnings, fmt.Sprintf("%s:%d unable to look up repository %s/%s: %v",
+						file.Path, usage.LineNumber(), usage.Spec.Owner, usage.Spec.Repo, err))
+				}
+			}
+
 			ref := usage.Ref
-			version, suffix := splitComment(usage.Comment)
+			if !isFullCommitSHA(ref) && cfg.Policy.Trusted(usage.Spec) {
+				continue
+			}
+			version, _ := splitComment(usage.Comment)
 			if version == "" {
 				if isFullCommitSHA(ref) {
+					tag, err := resolver.TagForCommit(usage.Spec.Owner, usage.Spec.Repo, ref)
+					if err != nil {
+						warnings = append(warnings, fmt.Sprintf("%s:%d unable to look up tags for %s: %v",
+							file.Path, usage.LineNumber(), usage.Spec.FullPath(), err))
+					} else if tag == "" {
+						warnings = append(warnings, fmt.Sprintf("%s:%d no tag of %s points at %s",
+							file.Path, usage.LineNumber(), usage.Spec.FullPath(), shortSHA(ref)))
+					} else {
+						usage.Set(ref, usage.CommentWithVersion(tag, "", style))
+					}
 					continue
 				}
 				version = ref
-				suffix = ""
 			}
 
-			_, commit, err := resolver.ResolveSpec(usage.Spec.Owner, usage.Spec.Repo, version)
+			// Refs name an existing tag or branch as written; only version
+			// comments are scoped to the action's tag family.
+			spec := version
+			if version != ref {
+				prefix, err := resolver.TagPrefix(usage.Spec, cfg.TagPrefixes)
+				if err != nil {
+					warnings = append(warnings, fmt.Sprintf("%s:%d unable to determine the tag prefix for %s: %v",
+						file.Path, usage.LineNumber(), usage.Spec.FullPath(), err))
+					continue
+				}
+				spec = withTagPrefix(version, prefix)
+			}
+
+			tag, commit, err := resolver.ResolveSpec(usage.Spec.Owner, usage.Spec.Repo, spec)
 			if err != nil {
 				warnings = append(warnings, fmt.Sprintf("%s:%d unable to resolve %s version %s: %v",
 					file.Path, usage.LineNumber(), usage.Spec.FullPath(), version, err))
 				continue
 			}
 
-			newComment := joinComment(version, suffix)
+			newComment := usage.CommentWithVersion(version, exactVersion(usage, tag, *exact), style)
 			if strings.EqualFold(commit, ref) && strings.EqualFold(newComment, usage.Comment) {
 				continue
 			}
 
 			usage.Set(commit, newComment)
-			updated++
 		}
 
 		if file.changed {
+			updated += len(file.changes)
 			if err := file.Save(); err != nil {
 				fmt.Fprintf(os.Stderr, "failed to write %s: %v\n", file.Path, err)
 				return 1
@@ -627,6 +1461,11 @@ func runFix(client restClient, files []*WorkflowFile) int {
 	}
 
 	fmt.Printf("Updated %d action reference(s) across %d file(s).\n", updated, filesChanged)
+
+	if err := publish.publish(client, files, "fix"); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
 	return 0
 }
 
@@ -636,6 +1475,13 @@ func runUpgrade(client restClient, files []*WorkflowFile, args []string) int {
 
 	all := fs.Bool("all", false, "upgrade all referenced actions")
 	versionFlag := fs.String("version", "", "upgrade to a specific release tag")
+	levelFlag := fs.String("level", "", "limit upgrades to patch, minor, or major bumps")
+	notes := fs.Bool("notes", false, "print release notes for every release between the current and target tags")
+
+	styleFlag := commentStyleFlag(fs)
+
+	var publish publishOptions
+	publish.register(fs)
 
 	if err := fs.Parse(args); err != nil {
 		return 1
@@ -656,6 +1502,34 @@ func runUpgrade(client restClient, files []*WorkflowFile, args []string) int {
 		return 1
 	}
 
+	level, ok := parseUpgradeLevel(*levelFlag)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown level %q (expected patch, minor, or major)\n", *levelFlag)
+		return 1
+	}
+
+	if level != levelAny && *versionFlag != "" {
+		fmt.Fprintln(os.Stderr, "--level cannot be combined with --version")
+		return 1
+	}
+
+	style, err := effectiveCommentStyle(*styleFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if err := publish.validate(files); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load configuration: %v\n", err)
+		return 1
+	}
+
 	resolver := NewTagResolver(client)
 
 	repoRecords := make(map[string]*repoRecord)
@@ -674,6 +1548,9 @@ func runUpgrade(client restClient, files []*WorkflowFile, args []string) int {
 				repoRecords[key] = record
 				repoOrder = append(repoOrder, key)
 			}
+			if !isFullCommitSHA(usage.Ref) && cfg.Policy.Trusted(usage.Spec) {
+				continue
+			}
 			record.Usages = append(record.Usages, usage)
 		}
 	}
@@ -685,17 +1562,46 @@ func runUpgrade(client restClient, files []*WorkflowFile, args []string) int {
 
 	var totalUpdates int
 	var filesChanged int
+	var heldBack []string
+	var pendingNotes []notesRequest
+	inputs := newStepInputIndex()
+
+	// applyFamily upgrades the usages of one tag family within a repository.
+	applyFamily := func(record *repoRecord, targetVersion string) (int, error) {
+		if level != levelAny {
+			selection, err := selectLevelTag(resolver, record, level)
+			if err != nil {
+				return 0, err
+			}
+			if selection.Tag == "" {
+				fmt.Fprintf(os.Stderr, "warning: skipping %s/%s: no semantic version comment found to apply --level %s\n",
+					record.Owner, record.Repo, level)
+				return 0, nil
+			}
+			if selection.HeldBack != "" {
+				heldBack = append(heldBack, fmt.Sprintf("%s/%s held back at %s; %s is available as a %s upgrade.",
+					record.Owner, record.Repo, selection.Tag, selection.HeldBack, selection.HeldBackLevel))
+			}
+			targetVersion = selection.Tag
+		}
 
-	applyRepo := func(record *repoRecord, targetVersion string) (int, error) {
-		version, commit, err := determineVersion(client, resolver, record.Owner, record.Repo, targetVersion)
+		version, commit, err := determineFamilyVersion(client, resolver, record.Owner, record.Repo, record.Prefix, targetVersion)
 		if err != nil {
 			return 0, err
 		}
 
+		changes, changeErrs := collectActionChanges(client, record.Usages, version, commit, inputs)
+		currentTag := ""
+		if *notes {
+			var err error
+			if currentTag, err = currentPinnedTag(resolver, record.Usages); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: unable to find the tag pinned for %s/%s: %v\n", record.Owner, record.Repo, err)
+			}
+		}
+
 		var modified int
 		for _, usage := range record.Usages {
-			_, suffix := splitComment(usage.Comment)
-			newComment := joinComment(version, suffix)
+			newComment := usage.CommentWithVersion(version, "", style)
 			if strings.EqualFold(usage.Ref, commit) && strings.EqualFold(usage.Comment, newComment) {
 				continue
 			}
@@ -705,10 +1611,42 @@ func runUpgrade(client restClient, files []*WorkflowFile, args []string) int {
 
 		if modified > 0 {
 			fmt.Printf("Upgraded %s/%s to %s (%s).\n", record.Owner, record.Repo, version, shortSHA(commit))
+			if currentTag != "" && !strings.EqualFold(currentTag, version) {
+				pendingNotes = append(pendingNotes, notesRequest{Owner: record.Owner, Repo: record.Repo, From: currentTag, To: version})
+			}
 		} else {
 			fmt.Printf("%s/%s is already at %s (%s).\n", record.Owner, record.Repo, version, shortSHA(commit))
 		}
 
+		for _, change := range changes {
+			if change.Empty() {
+				continue
+			}
+			writeActionChanges(os.Stdout, change)
+			writeActionChangeWarnings(os.Stderr, change)
+		}
+		for _, err := range changeErrs {
+			fmt.Fprintf(os.Stderr, "warning: unable to compare action metadata: %v\n", err)
+		}
+
+		return modified, nil
+	}
+
+	// applyRepo upgrades each tag family separately, so actions published
+	// from one repository under different tag prefixes move independently.
+	applyRepo := func(record *repoRecord, targetVersion string) (int, error) {
+		families, err := splitTagFamilies(resolver, record, cfg.TagPrefixes)
+		if err != nil {
+			return 0, err
+		}
+		var modified int
+		for _, family := range families {
+			count, err := applyFamily(family, targetVersion)
+			if err != nil {
+				return modified, err
+			}
+			modified += count
+		}
 		return modified, nil
 	}
 
@@ -736,6 +1674,10 @@ func runUpgrade(client restClient, files []*WorkflowFile, args []string) int {
 		totalUpdates += modified
 	}
 
+	for _, message := range heldBack {
+		fmt.Println(message)
+	}
+
 	for _, file := range files {
 		if file.changed {
 			if err := file.Save(); err != nil {
@@ -752,6 +1694,16 @@ func runUpgrade(client restClient, files []*WorkflowFile, args []string) int {
 	}
 
 	fmt.Printf("Updated %d action reference(s) across %d file(s).\n", totalUpdates, filesChanged)
+
+	if *notes {
+		for _, err := range writeReleaseNotes(os.Stdout, client, pendingNotes) {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+	}
+	if err := publish.publish(client, files, "upgrade"); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
 	return 0
 }
 
@@ -760,6 +1712,13 @@ func runUpdate(client restClient, files []*WorkflowFile, args []string) int {
 	fs.SetOutput(os.Stderr)
 
 	all := fs.Bool("all", false, "update all referenced actions")
+	notes := fs.Bool("notes", false, "print release notes for every release between the current and target tags")
+	exact := fs.Bool("exact", false, "record the resolved exact tag after floating version comments")
+
+	styleFlag := commentStyleFlag(fs)
+
+	var publish publishOptions
+	publish.register(fs)
 
 	if err := fs.Parse(args); err != nil {
 		return 1
@@ -775,6 +1734,23 @@ func runUpdate(client restClient, files []*WorkflowFile, args []string) int {
 		return 1
 	}
 
+	style, err := effectiveCommentStyle(*styleFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if err := publish.validate(files); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load configuration: %v\n", err)
+		return 1
+	}
+
 	resolver := NewTagResolver(client)
 	targetRepo := ""
 	if !*all {
@@ -800,21 +1776,42 @@ func runUpdate(client restClient, files []*WorkflowFile, args []string) int {
 			}
 			foundRepo = true
 
-			version, suffix := splitComment(usage.Comment)
+			if !isFullCommitSHA(usage.Ref) && cfg.Policy.Trusted(usage.Spec) {
+				continue
+			}
+
+			version, _ := splitComment(usage.Comment)
+			if version == "" && isFullCommitSHA(usage.Ref) {
+				// A bare SHA is treated as tracking the exact release it points at.
+				tag, err := resolver.TagForCommit(usage.Spec.Owner, usage.Spec.Repo, usage.Ref)
+				if err != nil {
+					warnings = append(warnings, fmt.Sprintf("%s:%d unable to look up tags for %s: %v",
+						file.Path, usage.LineNumber(), usage.Spec.FullPath(), err))
+					continue
+				}
+				version = tag
+			}
 			if version == "" {
 				warnings = append(warnings, fmt.Sprintf("%s:%d missing version comment for %s",
 					file.Path, usage.LineNumber(), usage.Spec.FullPath()))
 				continue
 			}
 
-			tag, commit, err := resolver.ResolveSpec(usage.Spec.Owner, usage.Spec.Repo, version)
+			prefix, err := resolver.TagPrefix(usage.Spec, cfg.TagPrefixes)
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("%s:%d unable to determine the tag prefix for %s: %v",
+					file.Path, usage.LineNumber(), usage.Spec.FullPath(), err))
+				continue
+			}
+
+			tag, commit, err := resolver.ResolveSpec(usage.Spec.Owner, usage.Spec.Repo, withTagPrefix(version, prefix))
 			if err != nil {
 				warnings = append(warnings, fmt.Sprintf("%s:%d unable to resolve %s spec %s: %v",
 					file.Path, usage.LineNumber(), usage.Spec.FullPath(), version, err))
 				continue
 			}
 
-			recordKey := fmt.Sprintf("%s|%s", repoKey, strings.ToLower(version))
+			recordKey := fmt.Sprintf("%s|%s|%s", repoKey, strings.ToLower(prefix), strings.ToLower(version))
 			record, exists := updateRecords[recordKey]
 			if !exists {
 				record = &updateRecord{
@@ -828,12 +1825,21 @@ func runUpdate(client restClient, files []*WorkflowFile, args []string) int {
 			record.Tag = tag
 			record.Commit = commit
 
-			newComment := joinComment(version, suffix)
+			newComment := usage.CommentWithVersion(version, exactVersion(usage, tag, *exact), style)
 			if strings.EqualFold(commit, usage.Ref) && strings.EqualFold(newComment, usage.Comment) {
 				record.Unchanged++
 				continue
 			}
 
+			if *notes {
+				from, err := pinnedTag(resolver, usage)
+				if err != nil {
+					warnings = append(warnings, fmt.Sprintf("%s:%d unable to find the tag pinned for %s: %v",
+						file.Path, usage.LineNumber(), usage.Spec.FullPath(), err))
+				}
+				record.From = olderTag(record.From, from)
+			}
+
 			usage.Set(commit, newComment)
 			record.Updated++
 			totalUpdates++
@@ -853,12 +1859,16 @@ func runUpdate(client restClient, files []*WorkflowFile, args []string) int {
 		return 1
 	}
 
+	var pendingNotes []notesRequest
 	sort.Strings(recordOrder)
 	for _, key := range recordOrder {
 		record := updateRecords[key]
 		if record.Updated > 0 {
 			fmt.Printf("Updated %s/%s spec %s to %s (%s).\n",
 				record.Owner, record.Repo, record.Spec, record.Tag, shortSHA(record.Commit))
+			if record.From != "" && !strings.EqualFold(record.From, record.Tag) {
+				pendingNotes = append(pendingNotes, notesRequest{Owner: record.Owner, Repo: record.Repo, From: record.From, To: record.Tag})
+			}
 		} else {
 			fmt.Printf("%s/%s spec %s already at %s (%s).\n",
 				record.Owner, record.Repo, record.Spec, record.Tag, shortSHA(record.Commit))
@@ -875,6 +1885,16 @@ func runUpdate(client restClient, files []*WorkflowFile, args []string) int {
 	}
 
 	fmt.Printf("Updated %d action reference(s) across %d file(s).\n", totalUpdates, filesChanged)
+
+	if *notes {
+		for _, err := range writeReleaseNotes(os.Stdout, client, pendingNotes) {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+	}
+	if err := publish.publish(client, files, "update"); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
 	return 0
 }
 
@@ -886,14 +1906,68 @@ type updateRecord struct {
 	Commit    string
 	Updated   int
 	Unchanged int
+	// From is the oldest tag the updated usages were pinned to, used as the
+	// start of the release notes.
+	From string
 }
 
 type repoRecord struct {
-	Owner  string
-	Repo   string
+	Owner string
+	Repo  string
+	// Prefix is the tag family the usages follow, such as "setup-foo/" for
+	// an action in a repository that publishes several.
+	Prefix string
 	Usages []*ActionUsage
 }
 
+// pinnedTag returns the exact tag a usage is pinned to: the most specific tag
+// its commit is on, or its version comment when the ref is not a SHA or no
+// tag points at the commit.
+func pinnedTag(resolver *TagResolver, usage *ActionUsage) (string, error) {
+	var err error
+	tag := ""
+	if isFullCommitSHA(usage.Ref) {
+		tag, err = resolver.TagForCommit(usage.Spec.Owner, usage.Spec.Repo, usage.Ref)
+	}
+	if tag == "" {
+		tag, _ = splitComment(usage.Comment)
+	}
+	return tag, err
+}
+
+// olderTag returns the older of two tags. Semantic versions win over other
+// tags, and an empty tag loses to anything.
+func olderTag(a, b string) string {
+	if a == "" {
+		return b
+	}
+	if b == "" {
+		return a
+	}
+	aVersion, aOK := parseTagVersion(a)
+	bVersion, bOK := parseTagVersion(b)
+	if bOK && (!aOK || bVersion.Compare(aVersion) < 0) {
+		return b
+	}
+	return a
+}
+
+// currentPinnedTag returns the oldest tag the usages are pinned to, so release
+// notes cover every release any of them moves past. Lookup errors are
+// returned after falling back to the version comment.
+func currentPinnedTag(resolver *TagResolver, usages []*ActionUsage) (string, error) {
+	current := ""
+	var firstErr error
+	for _, usage := range usages {
+		tag, err := pinnedTag(resolver, usage)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		current = olderTag(current, tag)
+	}
+	return current, firstErr
+}
+
 func determineVersion(client restClient, resolver *TagResolver, owner, repo, override string) (string, string, error) {
 	if override != "" {
 		tag, commit, err := resolver.ResolveSpec(owner, repo, override)
@@ -939,6 +2013,144 @@ func determineVersion(client restClient, resolver *TagResolver, owner, repo, ove
 	return tags[0].Name, strings.ToLower(tags[0].Commit.SHA), nil
 }
 
+type upgradeLevel int
+
+const (
+	levelAny upgradeLevel = iota
+	levelPatch
+	levelMinor
+	levelMajor
+)
+
+func (l upgradeLevel) String() string {
+	switch l {
+	case levelPatch:
+		return "patch"
+	case levelMinor:
+		return "minor"
+	case levelMajor:
+		return "major"
+	default:
+		return "any"
+	}
+}
+
+func parseUpgradeLevel(value string) (upgradeLevel, bool) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "":
+		return levelAny, true
+	case "patch":
+		return levelPatch, true
+	case "minor":
+		return levelMinor, true
+	case "major":
+		return levelMajor, true
+	default:
+		return levelAny, false
+	}
+}
+
+// bumpLevel classifies the change between two versions. Downgrades and
+// identical versions report levelAny.
+func bumpLevel(from, to semver) upgradeLevel {
+	switch {
+	case to.Compare(from) <= 0:
+		return levelAny
+	case to.Major != from.Major:
+		return levelMajor
+	case to.Minor != from.Minor:
+		return levelMinor
+	default:
+		return levelPatch
+	}
+}
+
+type levelSelection struct {
+	Tag           string
+	HeldBack      string
+	HeldBackLevel upgradeLevel
+}
+
+// selectLevelTag picks the newest release that is at most the given bump away
+// from the newest version currently referenced in the record's comments.
+// Floating comments such as v4 count as the newest release in that stream.
+// When a newer release exists beyond the allowed level it is reported as held
+// back. Only tags in the record's tag family are considered. When no comment
+// is a semantic version the selection is empty.
+func selectLevelTag(resolver *TagResolver, record *repoRecord, level upgradeLevel) (levelSelection, error) {
+	tags, err := resolver.ReleaseTags(record.Owner, record.Repo)
+	if err != nil {
+		return levelSelection{}, err
+	}
+
+	var current semver
+	currentTag := ""
+	for _, usage := range record.Usages {
+		version, _ := splitComment(usage.Comment)
+		if version == "" {
+			continue
+		}
+		version = withTagPrefix(version, record.Prefix)
+		kind, normalized := classifyVersionSpec(version)
+		if kind == specUnknown {
+			continue
+		}
+		candidate := version
+		if kind != specExact {
+			candidate = ""
+			for _, tag := range tags {
+				if matchVersionSpec(tag, normalized, kind) {
+					candidate = tag
+					break
+				}
+			}
+		}
+		parsed, ok := parseTagVersion(candidate)
+		if !ok {
+			continue
+		}
+		if currentTag == "" || parsed.Compare(current) > 0 {
+			current = parsed
+			currentTag = candidate
+		}
+	}
+	if currentTag == "" {
+		return levelSelection{}, nil
+	}
+
+	selection := levelSelection{Tag: currentTag}
+	var selected, newest semver
+	hasSelected := false
+	for _, tag := range tags {
+		if prefix, _ := splitTagPrefix(tag); !strings.EqualFold(prefix, record.Prefix) {
+			continue
+		}
+		parsed, ok := parseTagVersion(tag)
+		if !ok || parsed.Prerelease != "" {
+			continue
+		}
+		bump := bumpLevel(current, parsed)
+		if bump == levelAny {
+			continue
+		}
+		if bump <= level {
+			if !hasSelected || parsed.Compare(selected) > 0 {
+				selected = parsed
+				selection.Tag = tag
+				hasSelected = true
+			}
+			continue
+		}
+		if selection.HeldBack == "" || parsed.Compare(newest) > 0 {
+			newest = parsed
+			selection.HeldBack = tag
+			selection.HeldBackLevel = bump
+		}
+	}
+
+	return selection, nil
+}
+
 func loadWorkflowFiles() ([]*WorkflowFile, error) {
 	var paths []string
 	for _, root := range []struct {
@@ -991,22 +2203,41 @@ func loadWorkflowFiles() ([]*WorkflowFile, error) {
 		if err != nil {
 			return nil, err
 		}
-		lines := splitLines(string(content))
-		wf := &WorkflowFile{
-			Path:  path,
-			Lines: lines,
-			Uses:  []*ActionUsage{},
-		}
-		for idx, line := range lines {
-			if usage, ok := parseUsesLine(line); ok {
-				usage.File = wf
-				usage.Line = idx
-				wf.Uses = append(wf.Uses, usage)
-			}
+		files = append(files, newWorkflowFile(path, content))
+	}
+	return followLocalUsages(files)
+}
+
+const utf8BOM = "\ufeff"
+
+// newWorkflowFile parses file content into lines and usages, remembering the
+// byte-order mark, line ending and final newline so Save can reproduce them.
+func newWorkflowFile(path string, content []byte) *WorkflowFile {
+	text := string(content)
+	wf := &WorkflowFile{
+		Path: path,
+		Uses: []*ActionUsage{},
+	}
+	if strings.HasPrefix(text, utf8BOM) {
+		wf.bom = true
+		text = strings.TrimPrefix(text, utf8BOM)
+	}
+	if strings.Contains(text, "\r\n") {
+		wf.lineEnding = "\r\n"
+	}
+	wf.noFinalNewline = text != "" && !strings.HasSuffix(text, "\n")
+
+	wf.Lines = splitLines(text)
+	for idx, line := range wf.Lines {
+		if usage, ok := parseUsesLine(line); ok {
+			usage.File = wf
+			usage.Line = idx
+			wf.Uses = append(wf.Uses, usage)
+		} else if local, ok := parseLocalUsesLine(line); ok {
+			wf.Local = append(wf.Local, &LocalUsage{File: wf, Line: idx, Path: local})
 		}
-		files = append(files, wf)
 	}
-	return files, nil
+	return wf
 }
 
 func splitLines(s string) []string {
@@ -1027,19 +2258,38 @@ func parseUsesLine(line string) (*ActionUsage, bool) {
 	indent := line[:idx]
 	after := line[idx+len("uses:"):]
 	separator := after[:len(after)-len(strings.TrimLeft(after, " \t"))]
-	rest := strings.TrimSpace(after)
-	if rest == "" {
+	rest := after[len(separator):]
+	if strings.TrimSpace(rest) == "" {
 		return nil, false
 	}
 
-	valuePart, comment := splitValueAndComment(rest)
+	valuePart := rest
+	commentPrefix := ""
+	rawComment := ""
+	if hash := commentIndex(rest); hash >= 0 {
+		valuePart = rest[:hash]
+		rawComment = rest[hash+1:]
+		trimmedValue := strings.TrimRight(valuePart, " \t")
+		commentPrefix = valuePart[len(trimmedValue):] + "#"
+		valuePart = trimmedValue
+	}
+	trailingSource := valuePart
+	if commentPrefix != "" {
+		trailingSource = rawComment
+	}
+	trailing := trailingSource[len(strings.TrimRight(trailingSource, " \t")):]
+	if commentPrefix != "" {
+		rawComment = rawComment[:len(rawComment)-len(trailing)]
+	} else {
+		valuePart = valuePart[:len(valuePart)-len(trailing)]
+	}
 	if valuePart == "" {
 		return nil, false
 	}
 
-	quoted := false
+	quote := ""
 	if len(valuePart) >= 2 && ((valuePart[0] == '"' && valuePart[len(valuePart)-1] == '"') || (valuePart[0] == '\'' && valuePart[len(valuePart)-1] == '\'')) {
-		quoted = true
+		quote = valuePart[:1]
 		valuePart = valuePart[1 : len(valuePart)-1]
 	}
 
@@ -1062,6 +2312,9 @@ func parseUsesLine(line string) (*ActionUsage, bool) {
 	if refPart == "" {
 		return nil, false
 	}
+	if isFullCommitSHA(refPart) {
+		refPart = strings.ToLower(refPart)
+	}
 
 	specPieces := strings.Split(specPart, "/")
 	if len(specPieces) < 2 {
@@ -1079,17 +2332,43 @@ func parseUsesLine(line string) (*ActionUsage, bool) {
 	}
 
 	return &ActionUsage{
-		Indent:     indent,
-		Separator:  separator,
-		Quoted:     quoted,
-		Spec:       ActionSpec{Owner: owner, Repo: repo, Path: path},
-		Ref:        strings.ToLower(refPart),
-		Comment:    strings.TrimSpace(comment),
-		RawComment: strings.TrimSpace(comment),
+		Indent:        indent,
+		Separator:     separator,
+		Quote:         quote,
+		Spec:          ActionSpec{Owner: owner, Repo: repo, Path: path},
+		Ref:           refPart,
+		Comment:       strings.TrimSpace(rawComment),
+		RawComment:    rawComment,
+		CommentPrefix: commentPrefix,
+		Trailing:      trailing,
 	}, true
 }
 
-func splitValueAndComment(value string) (string, string) {
+// parseLocalUsesLine returns the path of a uses line that references the
+// same repository, such as "uses: ./.github/actions/setup". Relative and
+// absolute paths GitHub does not accept are returned too, so verify can
+// report them.
+func parseLocalUsesLine(line string) (string, bool) {
+	idx := strings.Index(line, "uses:")
+	if idx < 0 {
+		return "", false
+	}
+	value, _ := splitValueAndComment(line[idx+len("uses:"):])
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		value = value[1 : len(value)-1]
+	}
+	if strings.Contains(value, "${{") {
+		return "", false
+	}
+	if !strings.HasPrefix(value, "./") && !strings.HasPrefix(value, "../") && !strings.HasPrefix(value, "/") {
+		return "", false
+	}
+	return value, true
+}
+
+// commentIndex returns the index of the '#' that starts a comment in value,
+// ignoring any inside quotes, or -1 when there is no comment.
+func commentIndex(value string) int {
 	inSingle := false
 	inDouble := false
 	for i, r := range value {
@@ -1104,27 +2383,30 @@ func splitValueAndComment(value string) (string, string) {
 			}
 		case '#':
 			if !inSingle && !inDouble {
-				valuePart := strings.TrimSpace(value[:i])
-				comment := strings.TrimSpace(value[i+1:])
-				return valuePart, comment
+				return i
 			}
 		}
 	}
+	return -1
+}
+
+func splitValueAndComment(value string) (string, string) {
+	if i := commentIndex(value); i >= 0 {
+		return strings.TrimSpace(value[:i]), strings.TrimSpace(value[i+1:])
+	}
 	return strings.TrimSpace(value), ""
 }
 
+// splitComment returns the version named in a comment, in any recognized
+// comment style, and the text that follows it and any exact tag recorded
+// after it.
 func splitComment(comment string) (string, string) {
 	comment = strings.TrimSpace(comment)
-	if comment == "" {
-		return "", ""
-	}
-	fields := strings.Fields(comment)
-	if len(fields) == 0 {
-		return "", ""
+	parsed := parseVersionComment(comment)
+	if parsed.Version == "" {
+		return "", comment
 	}
-	version := fields[0]
-	rest := strings.TrimSpace(strings.TrimPrefix(comment, version))
-	return version, rest
+	return parsed.Version, strings.TrimSpace(comment[parsed.ExactEnd:])
 }
 
 func joinComment(version, suffix string) string {
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -47,6 +49,15 @@ func (m *mockRESTClient) withError(path string, err error) *mockRESTClient {
 	return m
 }
 
+func (m *mockRESTClient) withFile(repo, filePath, ref, content string) *mockRESTClient {
+	m.t.Helper()
+	return m.withJSON(fmt.Sprintf("repos/%s/contents/%s?ref=%s", repo, filePath, ref), map[string]interface{}{
+		"type":     "file",
+		"encoding": "base64",
+		"content":  base64.StdEncoding.EncodeToString([]byte(content)),
+	})
+}
+
 func (m *mockRESTClient) Get(path string, response interface{}) error {
 	m.callCounts[path]++
 	res, ok := m.responses[path]
@@ -129,6 +140,29 @@ func TestMatchVersionSpec(t *testing.T) {
 	}
 }
 
+func TestParseSemverCompare(t *testing.T) {
+	t.Parallel()
+	if _, ok := parseSemver("main"); ok {
+		t.Fatal("expected main not to parse as a version")
+	}
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"v1.2.3", "1.2.3", 0},
+		{"v2", "v1.9.9", 1},
+		{"v1.2", "v1.2.1", -1},
+		{"v1.0.0-beta.1", "v1.0.0", -1},
+	}
+	for _, tc := range cases {
+		a, _ := parseSemver(tc.a)
+		b, _ := parseSemver(tc.b)
+		if got := a.Compare(b); got != tc.want {
+			t.Fatalf("compare(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
 func TestSplitValueAndComment(t *testing.T) {
 	t.Parallel()
 	val, comment := splitValueAndComment(`actions/checkout@v3 # use latest v3`)
@@ -188,6 +222,127 @@ func TestParseUsesLine(t *testing.T) {
 	}
 }
 
+func TestParseUsesLinePreservesCase(t *testing.T) {
+	t.Parallel()
+	usage, ok := parseUsesLine(`- uses: owner/repo@Release-2024`)
+	if !ok || usage.Ref != "Release-2024" {
+		t.Fatalf("expected ref case to be preserved, got %+v", usage)
+	}
+	usage, ok = parseUsesLine(`- uses: owner/repo@ABCDEF1234567890ABCDEF1234567890ABCDEF12`)
+	if !ok || usage.Ref != "abcdef1234567890abcdef1234567890abcdef12" {
+		t.Fatalf("expected SHA to be lowercased, got %+v", usage)
+	}
+}
+
+func TestActionUsageSetPreservesFormatting(t *testing.T) {
+	t.Parallel()
+	const sha = "ABCDEF1234567890ABCDEF1234567890ABCDEF12"
+	cases := []struct {
+		name    string
+		line    string
+		ref     string
+		version string
+		want    string
+	}{
+		{
+			name:    "single quotes and comment spacing",
+			line:    `      - uses: 'owner/repo@Release-2024'   #   Release-2024   keep  this  `,
+			ref:     sha,
+			version: "Release-2024",
+			want:    `      - uses: 'owner/repo@` + strings.ToLower(sha) + `'   #   Release-2024   keep  this  `,
+		},
+		{
+			name:    "double quotes with new version",
+			line:    `  uses: "owner/repo@v1"  #v1	(pinned)`,
+			ref:     "Feature-X",
+			version: "v2",
+			want:    `  uses: "owner/repo@Feature-X"  #v2	(pinned)`,
+		},
+		{
+			name:    "comment added to bare ref",
+			line:    `  uses: owner/repo@v3 `,
+			ref:     sha,
+			version: "v3",
+			want:    `  uses: owner/repo@` + strings.ToLower(sha) + ` # v3 `,
+		},
+	}
+	for _, tc := range cases {
+		wf := buildWorkflowFile(t, tc.line)
+		usage := wf.Uses[0]
+		usage.Set(tc.ref, usage.CommentWithVersion(tc.version, "", ""))
+		if wf.Lines[0] != tc.want {
+			t.Fatalf("%s: line = %q, want %q", tc.name, wf.Lines[0], tc.want)
+		}
+	}
+}
+
+func TestWorkflowFileSavePreservesEncoding(t *testing.T) {
+	t.Parallel()
+	const sha = "abcdef1234567890abcdef1234567890abcdef12"
+	cases := []struct {
+		name    string
+		content string
+		want    string
+		mode    os.FileMode
+	}{
+		{
+			name:    "crlf with bom",
+			content: "\ufeffsteps:\r\n  - uses: owner/repo@v1\r\n",
+			want:    "\ufeffsteps:\r\n  - uses: owner/repo@" + sha + " # v1\r\n",
+			mode:    0o644,
+		},
+		{
+			name:    "no final newline",
+			content: "steps:\n  - uses: owner/repo@v1",
+			want:    "steps:\n  - uses: owner/repo@" + sha + " # v1",
+			mode:    0o600,
+		},
+		{
+			name:    "executable",
+			content: "steps:\n  - uses: owner/repo@v1\n",
+			want:    "steps:\n  - uses: owner/repo@" + sha + " # v1\n",
+			mode:    0o755,
+		},
+	}
+	for _, tc := range cases {
+		path := filepath.Join(t.TempDir(), "workflow.yml")
+		if err := os.WriteFile(path, []byte(tc.content), tc.mode); err != nil {
+			t.Fatalf("failed to seed workflow file: %v", err)
+		}
+		if err := os.Chmod(path, tc.mode); err != nil {
+			t.Fatalf("failed to set mode: %v", err)
+		}
+
+		wf := newWorkflowFile(path, []byte(tc.content))
+		if len(wf.Uses) != 1 {
+			t.Fatalf("%s: expected one usage, got %d", tc.name, len(wf.Uses))
+		}
+		wf.Uses[0].Set(sha, "v1")
+		if err := wf.Save(); err != nil {
+			t.Fatalf("%s: Save returned error: %v", tc.name, err)
+		}
+
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("%s: failed to read workflow file: %v", tc.name, err)
+		}
+		if string(got) != tc.want {
+			t.Fatalf("%s: content = %q, want %q", tc.name, got, tc.want)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("%s: failed to stat workflow file: %v", tc.name, err)
+		}
+		if info.Mode().Perm() != tc.mode {
+			t.Fatalf("%s: mode = %v, want %v", tc.name, info.Mode().Perm(), tc.mode)
+		}
+		entries, err := os.ReadDir(filepath.Dir(path))
+		if err != nil || len(entries) != 1 {
+			t.Fatalf("%s: expected no temporary files to remain, got %v (%v)", tc.name, entries, err)
+		}
+	}
+}
+
 func TestTagResolverResolveSpecMajor(t *testing.T) {
 	t.Parallel()
 	mock := newMockRESTClient(t).
@@ -343,6 +498,90 @@ func TestRunUpdate(t *testing.T) {
 	}
 }
 
+func TestRunUpgradeLevel(t *testing.T) {
+	t.Parallel()
+	const initialCommit = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	const minorCommit = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	const patchCommit = "cccccccccccccccccccccccccccccccccccccccc"
+
+	mock := newMockRESTClient(t).
+		withJSON("repos/actions/checkout/releases?per_page=100&page=1", []map[string]interface{}{
+			{"tag_name": "v5.0.0", "prerelease": false},
+			{"tag_name": "v4.3.0", "prerelease": false},
+			{"tag_name": "v4.2.0-rc.1", "prerelease": true},
+			{"tag_name": "v4.1.2", "prerelease": false},
+			{"tag_name": "v4.1.0", "prerelease": false},
+		}).
+		withJSON("repos/actions/checkout/git/ref/tags/v4.3.0", map[string]interface{}{
+			"object": map[string]interface{}{"sha": minorCommit, "type": "commit"},
+		}).
+		withJSON("repos/actions/checkout/git/ref/tags/v4.1.2", map[string]interface{}{
+			"object": map[string]interface{}{"sha": patchCommit, "type": "commit"},
+		})
+	for _, ref := range []string{initialCommit, minorCommit, patchCommit} {
+		mock.withFile("actions/checkout", "action.yml", ref, "runs:\n  using: node20\n")
+	}
+
+	cases := []struct {
+		level  string
+		commit string
+		tag    string
+	}{
+		{"minor", minorCommit, "v4.3.0"},
+		{"patch", patchCommit, "v4.1.2"},
+	}
+	for _, tc := range cases {
+		wf := buildWorkflowFile(t, `      - uses: actions/checkout@`+initialCommit+` # v4.1.0`)
+		exit := runUpgrade(mock, []*WorkflowFile{wf}, []string{"--level", tc.level, "actions/checkout"})
+		if exit != 0 {
+			t.Fatalf("runUpgrade --level %s exit = %d, want 0", tc.level, exit)
+		}
+		expectedLine := `      - uses: actions/checkout@` + tc.commit + ` # ` + tc.tag
+		if wf.Lines[0] != expectedLine {
+			t.Fatalf("--level %s line = %q, want %q", tc.level, wf.Lines[0], expectedLine)
+		}
+	}
+
+	resolver := NewTagResolver(mock)
+	record := &repoRecord{Owner: "actions", Repo: "checkout", Usages: []*ActionUsage{
+		{Comment: "v4"},
+	}}
+	selection, err := selectLevelTag(resolver, record, levelMinor)
+	if err != nil {
+		t.Fatalf("selectLevelTag error: %v", err)
+	}
+	if selection.Tag != "v4.3.0" || selection.HeldBack != "v5.0.0" || selection.HeldBackLevel != levelMajor {
+		t.Fatalf("unexpected selection for floating comment: %+v", selection)
+	}
+
+	wf := buildWorkflowFile(t, `      - uses: actions/checkout@`+initialCommit+` # v4.1.0`)
+	if exit := runUpgrade(mock, []*WorkflowFile{wf}, []string{"--level", "huge", "actions/checkout"}); exit == 0 {
+		t.Fatal("expected unknown level to fail")
+	}
+
+	// An action without a semantic version comment is skipped rather than
+	// aborting the whole run.
+	mock.withJSON("repos/octo/tool/releases?per_page=100&page=1", []map[string]interface{}{
+		{"tag_name": "v2.0.0", "prerelease": false},
+	})
+	wf = buildWorkflowFileFromLines(t,
+		`      - uses: octo/tool@`+initialCommit+` # main`,
+		`      - uses: actions/checkout@`+initialCommit+` # v4.1.0`,
+	)
+	if exit := runUpgrade(mock, []*WorkflowFile{wf}, []string{"--all", "--level", "minor"}); exit != 0 {
+		t.Fatalf("runUpgrade --all --level minor exit = %d, want 0", exit)
+	}
+	expected := []string{
+		`      - uses: octo/tool@` + initialCommit + ` # main`,
+		`      - uses: actions/checkout@` + minorCommit + ` # v4.3.0`,
+	}
+	for i, want := range expected {
+		if wf.Lines[i] != want {
+			t.Fatalf("line %d = %q, want %q", i+1, wf.Lines[i], want)
+		}
+	}
+}
+
 func TestRunFix(t *testing.T) {
 	t.Parallel()
 	const wrongCommit = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
@@ -360,7 +599,7 @@ func TestRunFix(t *testing.T) {
 		})
 
 	wf := buildWorkflowFile(t, `      - uses: actions/checkout@`+wrongCommit+` # v5.0.0`)
-	exit := runFix(mock, []*WorkflowFile{wf})
+	exit := runFix(mock, []*WorkflowFile{wf}, nil)
 	if exit != 0 {
 		t.Fatalf("runFix exit = %d, want 0", exit)
 	}
@@ -370,6 +609,161 @@ func TestRunFix(t *testing.T) {
 	}
 }
 
+func TestRunFixExact(t *testing.T) {
+	t.Parallel()
+	const olderCommit = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	const latestCommit = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+
+	mock := newMockRESTClient(t).
+		withJSON("repos/actions/checkout/releases?per_page=100&page=1", []map[string]interface{}{
+			{"tag_name": "v5.1.0", "prerelease": false},
+			{"tag_name": "v5.0.0", "prerelease": false},
+		}).
+		withJSON("repos/actions/checkout/git/ref/tags/v5.1.0", map[string]interface{}{
+			"object": map[string]interface{}{"sha": latestCommit, "type": "commit"},
+		}).
+		withJSON("repos/actions/checkout/git/ref/tags/v5.0.0", map[string]interface{}{
+			"object": map[string]interface{}{"sha": olderCommit, "type": "commit"},
+		}).
+		withJSON("repos/actions/checkout", map[string]interface{}{"full_name": "actions/checkout", "default_branch": "main"}).
+		withJSON("repos/actions/checkout/tags?per_page=100&page=1", []map[string]interface{}{
+			{"name": "v5.1.0", "commit": map[string]interface{}{"sha": latestCommit}},
+			{"name": "v5.0.0", "commit": map[string]interface{}{"sha": olderCommit}},
+		}).
+		withJSON("repos/actions/checkout/compare/main..."+latestCommit, map[string]interface{}{"status": "identical"})
+
+	wf := buildWorkflowFile(t, `      - uses: actions/checkout@v5 # v5 keep`)
+	if exit := runFix(mock, []*WorkflowFile{wf}, []string{"--exact"}); exit != 0 {
+		t.Fatalf("runFix exit = %d, want 0", exit)
+	}
+	expectedLine := `      - uses: actions/checkout@` + latestCommit + ` # v5 (v5.1.0) keep`
+	if wf.Lines[0] != expectedLine {
+		t.Fatalf("updated line = %q, want %q", wf.Lines[0], expectedLine)
+	}
+	if exit := runVerify(mock, []*WorkflowFile{wf}); exit != 0 {
+		t.Fatalf("runVerify exit = %d, want 0", exit)
+	}
+
+	stale := buildWorkflowFile(t, `      - uses: actions/checkout@`+latestCommit+` # v5 (v5.0.0)`)
+	if exit := runVerify(mock, []*WorkflowFile{stale}); exit == 0 {
+		t.Fatal("expected runVerify to report a stale exact tag")
+	}
+}
+
+func TestRunFixAnnotatesBareSHA(t *testing.T) {
+	t.Parallel()
+	const pinnedCommit = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	const otherCommit = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+
+	mock := newMockRESTClient(t).
+		withJSON("repos/actions/checkout/tags?per_page=100&page=1", []map[string]interface{}{
+			{"name": "v3", "commit": map[string]interface{}{"sha": pinnedCommit}},
+			{"name": "v3.6.0", "commit": map[string]interface{}{"sha": otherCommit}},
+			{"name": "v3.5", "commit": map[string]interface{}{"sha": pinnedCommit}},
+			{"name": "v3.5.2", "commit": map[string]interface{}{"sha": pinnedCommit}},
+			{"name": "latest", "commit": map[string]interface{}{"sha": pinnedCommit}},
+		}).
+		withJSON("repos/actions/checkout", map[string]interface{}{"default_branch": "main"}).
+		withJSON("repos/actions/checkout/releases?per_page=100&page=1", []map[string]interface{}{
+			{"tag_name": "v3.6.0", "prerelease": false},
+		}).
+		withJSON("repos/actions/checkout/compare/main..."+pinnedCommit, map[string]interface{}{"status": "behind", "behind_by": 5}).
+		withJSON("repos/actions/checkout/compare/main...cccccccccccccccccccccccccccccccccccccccc", map[string]interface{}{"status": "behind", "behind_by": 3}).
+		withJSON("repos/actions/checkout/compare/v3.6.0...cccccccccccccccccccccccccccccccccccccccc", map[string]interface{}{"status": "ahead", "ahead_by": 4})
+
+	wf := buildWorkflowFileFromLines(t,
+		`      - uses: actions/checkout@`+pinnedCommit,
+		`      - uses: actions/checkout@cccccccccccccccccccccccccccccccccccccccc`,
+	)
+	if exit := runVerify(mock, []*WorkflowFile{wf}); exit == 0 {
+		t.Fatal("expected runVerify to report missing version comments")
+	}
+	if exit := runFix(mock, []*WorkflowFile{wf}, nil); exit != 0 {
+		t.Fatalf("runFix exit = %d, want 0", exit)
+	}
+	expectedLine := `      - uses: actions/checkout@` + pinnedCommit + ` # v3.5.2`
+	if wf.Lines[0] != expectedLine {
+		t.Fatalf("updated line = %q, want %q", wf.Lines[0], expectedLine)
+	}
+	if wf.Lines[1] != `      - uses: actions/checkout@cccccccccccccccccccccccccccccccccccccccc` {
+		t.Fatalf("expected untagged commit to be left alone, got %q", wf.Lines[1])
+	}
+}
+
+func TestRunUpdateBareSHA(t *testing.T) {
+	t.Parallel()
+	const pinnedCommit = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+
+	mock := newMockRESTClient(t).
+		withJSON("repos/actions/checkout/tags?per_page=100&page=1", []map[string]interface{}{
+			{"name": "v3.5.2", "commit": map[string]interface{}{"sha": pinnedCommit}},
+		}).
+		withJSON("repos/actions/checkout/git/ref/tags/v3.5.2", map[string]interface{}{
+			"object": map[string]interface{}{"sha": pinnedCommit, "type": "commit"},
+		}).
+		withError("repos/octo/tool/tags?per_page=100&page=1", &api.HTTPError{StatusCode: 500, RequestURL: &url.URL{Path: "repos/octo/tool/tags"}})
+
+	wf := buildWorkflowFileFromLines(t,
+		`      - uses: actions/checkout@`+pinnedCommit,
+		`      - uses: octo/tool@`+pinnedCommit,
+	)
+	if exit := runUpdate(mock, []*WorkflowFile{wf}, []string{"--all"}); exit != 0 {
+		t.Fatalf("runUpdate exit = %d, want 0", exit)
+	}
+	expected := []string{
+		`      - uses: actions/checkout@` + pinnedCommit + ` # v3.5.2`,
+		`      - uses: octo/tool@` + pinnedCommit,
+	}
+	for i, want := range expected {
+		if wf.Lines[i] != want {
+			t.Fatalf("line %d = %q, want %q", i+1, wf.Lines[i], want)
+		}
+	}
+}
+
+func TestRepositoryChecks(t *testing.T) {
+	t.Parallel()
+	const commit = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+
+	mock := newMockRESTClient(t).
+		withJSON("repos/old-owner/tool", map[string]interface{}{"full_name": "new-owner/tool", "default_branch": "main"}).
+		withJSON("repos/octo/archived", map[string]interface{}{"full_name": "octo/archived", "archived": true}).
+		withError("repos/octo/gone", &api.HTTPError{StatusCode: 404, RequestURL: &url.URL{Path: "repos/octo/gone"}})
+
+	wf := buildWorkflowFileFromLines(t,
+		"      - uses: old-owner/tool/setup@"+commit+" # v1",
+		"      - uses: octo/archived@"+commit+" # v1",
+		"      - uses: octo/gone@"+commit+" # v1",
+	)
+
+	var issues []Issue
+	missing := checkRepositories(NewTagResolver(mock), []*WorkflowFile{wf}, func(issue Issue) {
+		issues = append(issues, issue)
+	})
+	if !missing["octo/gone"] || len(missing) != 1 {
+		t.Fatalf("expected only octo/gone to be missing, got %v", missing)
+	}
+	if len(issues) != 3 {
+		t.Fatalf("expected 3 issues, got %+v", issues)
+	}
+	if issues[0].Severity != severityWarning || !strings.Contains(issues[0].Message, "has moved to new-owner/tool") {
+		t.Fatalf("unexpected rename issue: %+v", issues[0])
+	}
+	if issues[1].Severity != severityWarning || !strings.Contains(issues[1].Message, "is archived") {
+		t.Fatalf("unexpected archive issue: %+v", issues[1])
+	}
+	if issues[2].Severity != severityError || issues[2].Line != 3 {
+		t.Fatalf("unexpected missing issue: %+v", issues[2])
+	}
+
+	if err := canonicalizeUsage(NewTagResolver(mock), wf.Uses[0]); err != nil {
+		t.Fatalf("canonicalizeUsage error: %v", err)
+	}
+	if want := "      - uses: new-owner/tool/setup@" + commit + " # v1"; wf.Lines[0] != want {
+		t.Fatalf("canonical line = %q, want %q", wf.Lines[0], want)
+	}
+}
+
 func TestRunVerify(t *testing.T) {
 	t.Parallel()
 	const correctCommit = "dddddddddddddddddddddddddddddddddddddddd"
@@ -384,7 +778,14 @@ func TestRunVerify(t *testing.T) {
 				"sha":  correctCommit,
 				"type": "commit",
 			},
-		})
+		}).
+		withJSON("repos/actions/checkout/tags?per_page=100&page=1", []map[string]interface{}{
+			{"name": "v5.0.0", "commit": map[string]interface{}{"sha": correctCommit}},
+		}).
+		withJSON("repos/actions/checkout", map[string]interface{}{"default_branch": "main"}).
+		withJSON("repos/actions/checkout/compare/main..."+correctCommit, map[string]interface{}{"status": "behind", "behind_by": 3}).
+		withJSON("repos/actions/checkout/compare/main..."+wrongCommit, map[string]interface{}{"status": "diverged", "ahead_by": 2, "behind_by": 5}).
+		withJSON("repos/actions/checkout/compare/v5.0.0..."+wrongCommit, map[string]interface{}{"status": "ahead", "ahead_by": 2})
 
 	t.Run("match", func(t *testing.T) {
 		wf := buildWorkflowFile(t, `      - uses: actions/checkout@`+correctCommit+` # v5.0.0`)
@@ -422,3 +823,26 @@ func buildWorkflowFile(t *testing.T, line string) *WorkflowFile {
 	usage.Line = 0
 	return wf
 }
+
+func buildWorkflowFileFromLines(t *testing.T, lines ...string) *WorkflowFile {
+	t.Helper()
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "workflow.yml")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatalf("failed to seed workflow file: %v", err)
+	}
+
+	wf := &WorkflowFile{
+		Path:  path,
+		Lines: append([]string(nil), lines...),
+		Uses:  []*ActionUsage{},
+	}
+	for idx, line := range lines {
+		if usage, ok := parseUsesLine(line); ok {
+			usage.File = wf
+			usage.Line = idx
+			wf.Uses = append(wf.Uses, usage)
+		}
+	}
+	return wf
+}
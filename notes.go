@@ -0,0 +1,142 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+)
+
+type releaseNote struct {
+	TagName     string `json:"tag_name"`
+	Name        string `json:"name"`
+	Body        string `json:"body"`
+	HTMLURL     string `json:"html_url"`
+	PublishedAt string `json:"published_at"`
+	Prerelease  bool   `json:"prerelease"`
+}
+
+// notesRequest describes a pending move of one action from one tag to
+// another.
+type notesRequest struct {
+	Owner string
+	Repo  string
+	From  string
+	To    string
+}
+
+// fetchReleaseNotes returns every non-prerelease release after from, up to and
+// including to, newest first. When either tag is not a semantic version only
+// the target release is returned.
+func fetchReleaseNotes(client restClient, owner, repo, from, to string) ([]releaseNote, error) {
+	// Repositories hosting several actions tag each one separately, so only
+	// releases in the target's tag family are collected.
+	prefix, _ := splitTagPrefix(to)
+	fromVersion, fromOK := parseTagVersion(from)
+	toVersion, toOK := parseTagVersion(to)
+
+	var notes []releaseNote
+	for page := 1; ; page++ {
+		var releases []releaseNote
+		path := fmt.Sprintf("repos/%s/%s/releases?per_page=%d&page=%d", owner, repo, listPageSize, page)
+		if err := client.Get(path, &releases); err != nil {
+			var httpErr *api.HTTPError
+			if errors.As(err, &httpErr) && httpErr.StatusCode == 404 {
+				break
+			}
+			return nil, err
+		}
+		for _, release := range releases {
+			if release.Prerelease {
+				continue
+			}
+			if !fromOK || !toOK {
+				if strings.EqualFold(release.TagName, to) {
+					notes = append(notes, release)
+				}
+				continue
+			}
+			if tagPrefix, _ := splitTagPrefix(release.TagName); !strings.EqualFold(tagPrefix, prefix) {
+				continue
+			}
+			version, ok := parseTagVersion(release.TagName)
+			if !ok {
+				continue
+			}
+			if version.Compare(fromVersion) > 0 && version.Compare(toVersion) <= 0 {
+				notes = append(notes, release)
+			}
+		}
+		if len(releases) < listPageSize {
+			break
+		}
+	}
+
+	sort.SliceStable(notes, func(i, j int) bool {
+		a, aOK := parseTagVersion(notes[i].TagName)
+		b, bOK := parseTagVersion(notes[j].TagName)
+		if !aOK || !bOK {
+			return false
+		}
+		return a.Compare(b) > 0
+	})
+	return notes, nil
+}
+
+// writeReleaseNotes renders a consolidated markdown changelog for each
+// request. Requests that fail to load are skipped and returned as errors.
+func writeReleaseNotes(w io.Writer, client restClient, requests []notesRequest) []error {
+	var errs []error
+	seen := make(map[string]struct{})
+	wroteHeader := false
+
+	for _, request := range requests {
+		key := strings.ToLower(fmt.Sprintf("%s/%s@%s..%s", request.Owner, request.Repo, request.From, request.To))
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+
+		notes, err := fetchReleaseNotes(client, request.Owner, request.Repo, request.From, request.To)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to fetch release notes for %s/%s: %w", request.Owner, request.Repo, err))
+			continue
+		}
+
+		if !wroteHeader {
+			fmt.Fprintln(w)
+			fmt.Fprintln(w, "# Release notes")
+			wroteHeader = true
+		}
+
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "## %s/%s %s → %s\n", request.Owner, request.Repo, request.From, request.To)
+		if len(notes) == 0 {
+			fmt.Fprintln(w)
+			fmt.Fprintln(w, "_No releases found in this range._")
+			continue
+		}
+		for _, note := range notes {
+			fmt.Fprintln(w)
+			heading := note.TagName
+			if note.Name != "" && note.Name != note.TagName {
+				heading = fmt.Sprintf("%s: %s", note.TagName, note.Name)
+			}
+			if note.HTMLURL != "" {
+				heading = fmt.Sprintf("[%s](%s)", heading, note.HTMLURL)
+			}
+			if date, _, ok := strings.Cut(note.PublishedAt, "T"); ok {
+				heading = fmt.Sprintf("%s (%s)", heading, date)
+			}
+			fmt.Fprintf(w, "### %s\n", heading)
+			if body := strings.TrimSpace(strings.ReplaceAll(note.Body, "\r\n", "\n")); body != "" {
+				fmt.Fprintln(w)
+				fmt.Fprintln(w, body)
+			}
+		}
+	}
+	return errs
+}
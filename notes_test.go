@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteReleaseNotes(t *testing.T) {
+	t.Parallel()
+	mock := newMockRESTClient(t).
+		withJSON("repos/actions/checkout/releases?per_page=100&page=1", []map[string]interface{}{
+			{"tag_name": "v5.0.0", "prerelease": false, "body": "Breaking: node24"},
+			{"tag_name": "v4.3.0-rc.1", "prerelease": true, "body": "candidate"},
+			{"tag_name": "v4.2.0", "prerelease": false, "body": "Add sparse checkout", "published_at": "2024-10-01T12:00:00Z"},
+			{"tag_name": "v4.1.0", "prerelease": false, "body": "Already pinned"},
+		})
+
+	notes, err := fetchReleaseNotes(mock, "actions", "checkout", "v4.1.0", "v5.0.0")
+	if err != nil {
+		t.Fatalf("fetchReleaseNotes error: %v", err)
+	}
+	if len(notes) != 2 || notes[0].TagName != "v5.0.0" || notes[1].TagName != "v4.2.0" {
+		t.Fatalf("unexpected notes: %+v", notes)
+	}
+
+	var out bytes.Buffer
+	errs := writeReleaseNotes(&out, mock, []notesRequest{
+		{Owner: "actions", Repo: "checkout", From: "v4.1.0", To: "v5.0.0"},
+		{Owner: "actions", Repo: "checkout", From: "v4.1.0", To: "v5.0.0"},
+	})
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	rendered := out.String()
+	for _, want := range []string{
+		"# Release notes",
+		"## actions/checkout v4.1.0 → v5.0.0",
+		"### v5.0.0",
+		"Breaking: node24",
+		"### v4.2.0 (2024-10-01)",
+	} {
+		if !strings.Contains(rendered, want) {
+			t.Fatalf("notes missing %q:\n%s", want, rendered)
+		}
+	}
+	if strings.Contains(rendered, "candidate") || strings.Contains(rendered, "Already pinned") {
+		t.Fatalf("notes include releases outside the range:\n%s", rendered)
+	}
+	if strings.Count(rendered, "## actions/checkout") != 1 {
+		t.Fatalf("duplicate requests should render once:\n%s", rendered)
+	}
+}
+
+func TestCurrentPinnedTag(t *testing.T) {
+	t.Parallel()
+	const olderCommit = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	const newerCommit = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	const untaggedCommit = "cccccccccccccccccccccccccccccccccccccccc"
+
+	mock := newMockRESTClient(t).
+		withJSON("repos/actions/checkout/tags?per_page=100&page=1", []map[string]interface{}{
+			{"name": "v4", "commit": map[string]interface{}{"sha": newerCommit}},
+			{"name": "v4.2.0", "commit": map[string]interface{}{"sha": newerCommit}},
+			{"name": "v4.1.0", "commit": map[string]interface{}{"sha": olderCommit}},
+		})
+	resolver := NewTagResolver(mock)
+	spec := ActionSpec{Owner: "actions", Repo: "checkout"}
+
+	// Floating comments do not lower the start of the notes to v4.0.0; the
+	// pinned commits are on v4.1.0 and v4.2.0.
+	tag, err := currentPinnedTag(resolver, []*ActionUsage{
+		{Spec: spec, Ref: newerCommit, Comment: "v4"},
+		{Spec: spec, Ref: olderCommit, Comment: "v4"},
+	})
+	if err != nil {
+		t.Fatalf("currentPinnedTag error: %v", err)
+	}
+	if tag != "v4.1.0" {
+		t.Fatalf("currentPinnedTag = %q, want v4.1.0", tag)
+	}
+
+	tag, err = currentPinnedTag(resolver, []*ActionUsage{{Spec: spec, Ref: untaggedCommit, Comment: "v4.0.3"}})
+	if err != nil || tag != "v4.0.3" {
+		t.Fatalf("expected an untagged commit to fall back to its comment, got %q, %v", tag, err)
+	}
+}
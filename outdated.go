@@ -0,0 +1,284 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+)
+
+type outdatedEntry struct {
+	Action       string   `json:"action"`
+	Owner        string   `json:"owner"`
+	Repo         string   `json:"repo"`
+	Spec         string   `json:"spec"`
+	Current      string   `json:"current"`
+	Wanted       string   `json:"wanted"`
+	WantedCommit string   `json:"wantedCommit"`
+	Latest       string   `json:"latest"`
+	LatestCommit string   `json:"latestCommit"`
+	MajorBump    bool     `json:"majorBump"`
+	Outdated     bool     `json:"outdated"`
+	Locations    []string `json:"locations"`
+}
+
+func cmdOutdated(args []string) int {
+	client, err := api.DefaultRESTClient()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create GitHub client: %v\n", err)
+		return 1
+	}
+
+	files, err := loadWorkflowFiles()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load workflow files: %v\n", err)
+		return 1
+	}
+
+	if len(allUsages(files)) == 0 {
+		fmt.Println("No workflow or composite action usages found.")
+		return 0
+	}
+
+	exit := runOutdated(os.Stdout, client, files, args)
+	return exit
+}
+
+func runOutdated(w io.Writer, client restClient, files []*WorkflowFile, args []string) int {
+	fs := flag.NewFlagSet("outdated", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	format := fs.String("format", "table", "output format (table, json)")
+	exitCode := fs.Bool("exit-code", false, "exit with status 1 when any action is outdated")
+	notes := fs.Bool("notes", false, "print release notes for every release between the current and latest tags")
+
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+
+	if fs.NArg() > 1 {
+		fmt.Fprintln(os.Stderr, "outdated accepts at most one owner/repo argument")
+		return 1
+	}
+
+	if *format != "table" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "unknown format %q (expected table or json)\n", *format)
+		return 1
+	}
+
+	if *notes && *format != "table" {
+		fmt.Fprintln(os.Stderr, "--notes is only supported with --format table")
+		return 1
+	}
+
+	targetRepo := ""
+	if fs.NArg() == 1 {
+		targetRepo = strings.ToLower(fs.Arg(0))
+		if strings.Count(targetRepo, "/") != 1 {
+			fmt.Fprintln(os.Stderr, "repository argument must be in the form owner/repo")
+			return 1
+		}
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load configuration: %v\n", err)
+		return 1
+	}
+
+	entries, warnings, err := collectOutdated(client, files, targetRepo, cfg.TagPrefixes)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	switch *format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(entries)
+	default:
+		err = writeOutdatedTable(w, entries)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write report: %v\n", err)
+		return 1
+	}
+
+	if *notes {
+		var requests []notesRequest
+		for _, entry := range entries {
+			if entry.Outdated && !strings.EqualFold(entry.Current, entry.Latest) {
+				requests = append(requests, notesRequest{Owner: entry.Owner, Repo: entry.Repo, From: entry.Current, To: entry.Latest})
+			}
+		}
+		for _, err := range writeReleaseNotes(w, client, requests) {
+			warnings = append(warnings, fmt.Sprintf("warning: %v", err))
+		}
+	}
+
+	for _, warning := range warnings {
+		fmt.Fprintln(os.Stderr, warning)
+	}
+
+	if *exitCode {
+		for _, entry := range entries {
+			if entry.Outdated {
+				return 1
+			}
+		}
+	}
+	return 0
+}
+
+// collectOutdated resolves, for every action, version spec and pinned commit,
+// the tag the commit is currently on and both the tag update would select and
+// the tag upgrade would select, without modifying any files. Usages pinned to
+// a tag or branch are compared by the commit the ref resolves to. Actions
+// that publish their own tag family are compared within that family.
+func collectOutdated(client restClient, files []*WorkflowFile, targetRepo string, prefixes map[string]string) ([]*outdatedEntry, []string, error) {
+	resolver := NewTagResolver(client)
+	entries := make(map[string]*outdatedEntry)
+	var order []string
+	var warnings []string
+	foundRepo := targetRepo == ""
+
+	type latestRelease struct {
+		tag    string
+		commit string
+		err    error
+	}
+	latest := make(map[string]latestRelease)
+
+	for _, usage := range allUsages(files) {
+		repoKey := usage.Spec.RepoKey()
+		if targetRepo != "" && repoKey != targetRepo {
+			continue
+		}
+		foundRepo = true
+
+		prefix, err := resolver.TagPrefix(usage.Spec, prefixes)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s:%d unable to determine the tag prefix for %s: %v",
+				usage.File.Path, usage.LineNumber(), usage.Spec.FullPath(), err))
+			continue
+		}
+
+		version, _ := splitComment(usage.Comment)
+		spec := withTagPrefix(version, prefix)
+		if version == "" {
+			if isFullCommitSHA(usage.Ref) {
+				warnings = append(warnings, fmt.Sprintf("%s:%d missing version comment for %s",
+					usage.File.Path, usage.LineNumber(), usage.Spec.FullPath()))
+				continue
+			}
+			version, spec = usage.Ref, usage.Ref
+		}
+
+		pinned, err := resolver.ResolveRef(usage.Spec.Owner, usage.Spec.Repo, usage.Ref)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s:%d unable to resolve %s ref %s: %v",
+				usage.File.Path, usage.LineNumber(), usage.Spec.FullPath(), usage.Ref, err))
+			continue
+		}
+
+		key := fmt.Sprintf("%s|%s|%s|%s", repoKey, strings.ToLower(prefix), strings.ToLower(version), pinned)
+		entry, exists := entries[key]
+		if !exists {
+			current, err := resolver.TagForCommit(usage.Spec.Owner, usage.Spec.Repo, pinned)
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("%s:%d unable to find the tag for %s@%s: %v",
+					usage.File.Path, usage.LineNumber(), usage.Spec.FullPath(), shortSHA(pinned), err))
+			}
+			if current == "" {
+				current = refLabel(usage.Ref)
+			}
+
+			tag, commit, err := resolver.ResolveSpec(usage.Spec.Owner, usage.Spec.Repo, spec)
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("%s:%d unable to resolve %s spec %s: %v",
+					usage.File.Path, usage.LineNumber(), usage.Spec.FullPath(), version, err))
+				continue
+			}
+
+			latestKey := repoKey + "|" + strings.ToLower(prefix)
+			release, ok := latest[latestKey]
+			if !ok {
+				release.tag, release.commit, release.err = determineFamilyVersion(client, resolver, usage.Spec.Owner, usage.Spec.Repo, prefix, "")
+				latest[latestKey] = release
+			}
+			if release.err != nil {
+				warnings = append(warnings, fmt.Sprintf("unable to determine latest release for %s/%s: %v",
+					usage.Spec.Owner, usage.Spec.Repo, release.err))
+				release.tag, release.commit = tag, commit
+			}
+
+			action := fmt.Sprintf("%s/%s", usage.Spec.Owner, usage.Spec.Repo)
+			if prefix != "" {
+				action = usage.Spec.FullPath()
+			}
+			entry = &outdatedEntry{
+				Action:       action,
+				Owner:        usage.Spec.Owner,
+				Repo:         usage.Spec.Repo,
+				Spec:         version,
+				Current:      current,
+				Wanted:       tag,
+				WantedCommit: commit,
+				Latest:       release.tag,
+				LatestCommit: release.commit,
+				MajorBump:    isMajorBump(version, release.tag),
+				Outdated:     pinned != commit || pinned != release.commit,
+			}
+			entries[key] = entry
+			order = append(order, key)
+		}
+
+		entry.Locations = append(entry.Locations, fmt.Sprintf("%s:%d", usage.File.Path, usage.LineNumber()))
+	}
+
+	if !foundRepo {
+		return nil, nil, fmt.Errorf("repository %s not referenced in workflows or composite actions", targetRepo)
+	}
+
+	sort.Strings(order)
+	result := make([]*outdatedEntry, 0, len(order))
+	for _, key := range order {
+		result = append(result, entries[key])
+	}
+	return result, warnings, nil
+}
+
+func writeOutdatedTable(w io.Writer, entries []*outdatedEntry) error {
+	var outdated []*outdatedEntry
+	for _, entry := range entries {
+		if entry.Outdated {
+			outdated = append(outdated, entry)
+		}
+	}
+	if len(outdated) == 0 {
+		fmt.Fprintln(w, "All actions are up to date.")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ACTION\tCURRENT\tWANTED\tLATEST\tNOTE")
+	for _, entry := range outdated {
+		note := ""
+		if entry.MajorBump {
+			note = "major version change"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s (%s)\t%s (%s)\t%s\n",
+			entry.Action, entry.Current,
+			entry.Wanted, shortSHA(entry.WantedCommit),
+			entry.Latest, shortSHA(entry.LatestCommit),
+			note)
+	}
+	return tw.Flush()
+}
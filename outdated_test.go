@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRunOutdated(t *testing.T) {
+	t.Parallel()
+	const pinnedCommit = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	const wantedCommit = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	const latestCommit = "cccccccccccccccccccccccccccccccccccccccc"
+
+	mock := newMockRESTClient(t).
+		withJSON("repos/actions/checkout/releases?per_page=100&page=1", []map[string]interface{}{
+			{"tag_name": "v5.0.0", "prerelease": false},
+			{"tag_name": "v4.2.1", "prerelease": false},
+		}).
+		withJSON("repos/actions/checkout/git/ref/tags/v4.2.1", map[string]interface{}{
+			"object": map[string]interface{}{"sha": wantedCommit, "type": "commit"},
+		}).
+		withJSON("repos/actions/checkout/releases/latest", map[string]interface{}{
+			"tag_name": "v5.0.0",
+		}).
+		withJSON("repos/actions/checkout/git/ref/tags/v5.0.0", map[string]interface{}{
+			"object": map[string]interface{}{"sha": latestCommit, "type": "commit"},
+		}).
+		withJSON("repos/actions/checkout/tags?per_page=100&page=1", []map[string]interface{}{
+			{"name": "v5.0.0", "commit": map[string]interface{}{"sha": latestCommit}},
+			{"name": "v5", "commit": map[string]interface{}{"sha": latestCommit}},
+			{"name": "v4.1.0", "commit": map[string]interface{}{"sha": pinnedCommit}},
+		}).
+		withJSON("repos/actions/checkout/commits/v5", map[string]interface{}{"sha": latestCommit})
+
+	wf := buildWorkflowFile(t, `      - uses: actions/checkout@`+pinnedCommit+` # v4`)
+	original := wf.Lines[0]
+
+	var out bytes.Buffer
+	if exit := runOutdated(&out, mock, []*WorkflowFile{wf}, []string{"--format", "json", "--exit-code"}); exit != 1 {
+		t.Fatalf("runOutdated exit = %d, want 1", exit)
+	}
+	if wf.Lines[0] != original || wf.changed {
+		t.Fatal("outdated must not modify workflow files")
+	}
+
+	var entries []outdatedEntry
+	if err := json.Unmarshal(out.Bytes(), &entries); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	entry := entries[0]
+	if entry.Current != "v4.1.0" || entry.Wanted != "v4.2.1" || entry.Latest != "v5.0.0" {
+		t.Fatalf("unexpected current/wanted/latest: %+v", entry)
+	}
+	if !entry.MajorBump || !entry.Outdated {
+		t.Fatalf("expected outdated major bump: %+v", entry)
+	}
+
+	out.Reset()
+	if exit := runOutdated(&out, mock, []*WorkflowFile{wf}, nil); exit != 0 {
+		t.Fatalf("runOutdated without --exit-code = %d, want 0", exit)
+	}
+	if !strings.Contains(out.String(), "major version change") {
+		t.Fatalf("expected major bump note in table:\n%s", out.String())
+	}
+
+	// A tag ref that already resolves to the latest release is up to date
+	// even though it is not pinned to a SHA.
+	tagged := buildWorkflowFile(t, `      - uses: actions/checkout@v5`)
+	out.Reset()
+	if exit := runOutdated(&out, mock, []*WorkflowFile{tagged}, []string{"--format", "json", "--exit-code"}); exit != 0 {
+		t.Fatalf("runOutdated for an up-to-date tag ref = %d, want 0", exit)
+	}
+	entries = nil
+	if err := json.Unmarshal(out.Bytes(), &entries); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Outdated || entries[0].Current != "v5.0.0" {
+		t.Fatalf("expected an up-to-date entry on v5.0.0, got %+v", entries)
+	}
+}
+
+func TestIsMajorBump(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		from, to string
+		want     bool
+	}{
+		{"v4", "v5.0.0", true},
+		{"v4.1.0", "v4.2.0", false},
+		{"v4.1.0", "v3.9.0", false},
+		{"main", "v5.0.0", false},
+	}
+	for _, tc := range cases {
+		if got := isMajorBump(tc.from, tc.to); got != tc.want {
+			t.Fatalf("isMajorBump(%q, %q) = %v, want %v", tc.from, tc.to, got, tc.want)
+		}
+	}
+}
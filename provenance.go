@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// maxNearestTagChecks caps how many release tags, newest first, are compared
+// against a commit when looking for the release it was built on.
+const maxNearestTagChecks = 10
+
+// commitProvenance describes where a pinned commit sits relative to the
+// action's releases.
+type commitProvenance struct {
+	// Tag is set when a tag points exactly at the commit.
+	Tag string
+	// NearestTag is the newest release tag the commit descends from, and
+	// AheadBy the number of commits it is ahead of that tag.
+	NearestTag string
+	AheadBy    int
+	// OnDefaultBranch reports whether the commit is reachable from the
+	// repository's default branch, named by DefaultBranch.
+	OnDefaultBranch bool
+	DefaultBranch   string
+}
+
+// provenanceClassifier classifies pinned commits via the tags and compare
+// APIs, caching per-repository lookups.
+type provenanceClassifier struct {
+	client   restClient
+	resolver *TagResolver
+	results  map[string]commitProvenance
+}
+
+func newProvenanceClassifier(client restClient, resolver *TagResolver) *provenanceClassifier {
+	return &provenanceClassifier{
+		client:   client,
+		resolver: resolver,
+		results:  make(map[string]commitProvenance),
+	}
+}
+
+type compareResult struct {
+	Status   string `json:"status"`
+	AheadBy  int    `json:"ahead_by"`
+	BehindBy int    `json:"behind_by"`
+}
+
+func (c *provenanceClassifier) compare(owner, repo, base, head string) (compareResult, error) {
+	var result compareResult
+	path := fmt.Sprintf("repos/%s/%s/compare/%s...%s", owner, repo, url.PathEscape(base), url.PathEscape(head))
+	err := c.client.Get(path, &result)
+	return result, err
+}
+
+// Classify reports whether the commit is exactly a tag and whether it is on
+// the default branch, and for untagged commits which release it is ahead of.
+func (c *provenanceClassifier) Classify(owner, repo, sha string) (commitProvenance, error) {
+	key := strings.ToLower(fmt.Sprintf("%s/%s@%s", owner, repo, sha))
+	if cached, ok := c.results[key]; ok {
+		return cached, nil
+	}
+
+	var result commitProvenance
+	tag, err := c.resolver.TagForCommit(owner, repo, sha)
+	if err != nil {
+		return result, err
+	}
+	result.Tag = tag
+
+	info, err := c.resolver.Repository(owner, repo)
+	if err != nil {
+		return result, fmt.Errorf("failed to look up default branch: %w", err)
+	}
+	branch := info.DefaultBranch
+	result.DefaultBranch = branch
+	onBranch, err := c.compare(owner, repo, branch, sha)
+	if err != nil {
+		return result, fmt.Errorf("failed to compare %s with %s: %w", shortSHA(sha), branch, err)
+	}
+	result.OnDefaultBranch = onBranch.Status == "behind" || onBranch.Status == "identical"
+	if tag != "" {
+		c.results[key] = result
+		return result, nil
+	}
+
+	tags, err := c.resolver.ReleaseTags(owner, repo)
+	if err != nil {
+		return result, err
+	}
+	if len(tags) > maxNearestTagChecks {
+		tags = tags[:maxNearestTagChecks]
+	}
+	for _, candidate := range tags {
+		comparison, err := c.compare(owner, repo, candidate, sha)
+		if err != nil {
+			return result, fmt.Errorf("failed to compare %s with %s: %w", shortSHA(sha), candidate, err)
+		}
+		if comparison.Status == "ahead" {
+			result.NearestTag = candidate
+			result.AheadBy = comparison.AheadBy
+			break
+		}
+	}
+
+	c.results[key] = result
+	return result, nil
+}
+
+// Released reports whether the commit is exactly a tag on the default branch,
+// the only provenance verify does not warn about.
+func (p commitProvenance) Released() bool {
+	return p.Tag != "" && p.OnDefaultBranch
+}
+
+// Describe summarizes a provenance that is not Released for a warning.
+func (p commitProvenance) Describe() string {
+	if p.Tag != "" {
+		return fmt.Sprintf("is tag %s, but is not on the default branch %s", p.Tag, p.DefaultBranch)
+	}
+	nearest := "no recent release precedes it"
+	if p.NearestTag != "" {
+		nearest = fmt.Sprintf("%d commit(s) ahead of %s", p.AheadBy, p.NearestTag)
+	}
+	if !p.OnDefaultBranch {
+		return fmt.Sprintf("is not on the default branch %s (%s)", p.DefaultBranch, nearest)
+	}
+	return fmt.Sprintf("is between releases (%s)", nearest)
+}
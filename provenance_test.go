@@ -0,0 +1,67 @@
+package main
+
+import "testing"
+
+func TestProvenanceClassifier(t *testing.T) {
+	t.Parallel()
+	const taggedCommit = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	const betweenCommit = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	const branchCommit = "cccccccccccccccccccccccccccccccccccccccc"
+	const releaseBranchCommit = "eeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee"
+
+	mock := newMockRESTClient(t).
+		withJSON("repos/octo/action/tags?per_page=100&page=1", []map[string]interface{}{
+			{"name": "v1.1.0", "commit": map[string]interface{}{"sha": "dddddddddddddddddddddddddddddddddddddddd"}},
+			{"name": "v1.0.0", "commit": map[string]interface{}{"sha": taggedCommit}},
+			{"name": "v0.9.1", "commit": map[string]interface{}{"sha": releaseBranchCommit}},
+		}).
+		withJSON("repos/octo/action", map[string]interface{}{"default_branch": "main"}).
+		withJSON("repos/octo/action/releases?per_page=100&page=1", []map[string]interface{}{
+			{"tag_name": "v1.1.0", "prerelease": false},
+			{"tag_name": "v1.0.0", "prerelease": false},
+		}).
+		withJSON("repos/octo/action/compare/main..."+taggedCommit, map[string]interface{}{"status": "behind", "behind_by": 12}).
+		withJSON("repos/octo/action/compare/main..."+releaseBranchCommit, map[string]interface{}{"status": "diverged", "ahead_by": 1, "behind_by": 20}).
+		withJSON("repos/octo/action/compare/main..."+betweenCommit, map[string]interface{}{"status": "behind", "behind_by": 4}).
+		withJSON("repos/octo/action/compare/v1.1.0..."+betweenCommit, map[string]interface{}{"status": "behind", "behind_by": 1}).
+		withJSON("repos/octo/action/compare/v1.0.0..."+betweenCommit, map[string]interface{}{"status": "ahead", "ahead_by": 3}).
+		withJSON("repos/octo/action/compare/main..."+branchCommit, map[string]interface{}{"status": "diverged", "ahead_by": 1, "behind_by": 9}).
+		withJSON("repos/octo/action/compare/v1.1.0..."+branchCommit, map[string]interface{}{"status": "diverged", "ahead_by": 1, "behind_by": 2}).
+		withJSON("repos/octo/action/compare/v1.0.0..."+branchCommit, map[string]interface{}{"status": "ahead", "ahead_by": 6})
+
+	classifier := newProvenanceClassifier(mock, NewTagResolver(mock))
+
+	tagged, err := classifier.Classify("octo", "action", taggedCommit)
+	if err != nil || tagged.Tag != "v1.0.0" || !tagged.Released() {
+		t.Fatalf("expected tagged commit to resolve to v1.0.0, got %+v (%v)", tagged, err)
+	}
+
+	// A tag on another branch is still reported.
+	offBranch, err := classifier.Classify("octo", "action", releaseBranchCommit)
+	if err != nil {
+		t.Fatalf("Classify error: %v", err)
+	}
+	if want := "is tag v0.9.1, but is not on the default branch main"; offBranch.Released() || offBranch.Describe() != want {
+		t.Fatalf("off-branch tag = %q, want %q", offBranch.Describe(), want)
+	}
+
+	between, err := classifier.Classify("octo", "action", betweenCommit)
+	if err != nil {
+		t.Fatalf("Classify error: %v", err)
+	}
+	if want := "is between releases (3 commit(s) ahead of v1.0.0)"; between.Describe() != want {
+		t.Fatalf("between = %q, want %q", between.Describe(), want)
+	}
+
+	branch, err := classifier.Classify("octo", "action", branchCommit)
+	if err != nil {
+		t.Fatalf("Classify error: %v", err)
+	}
+	if want := "is not on the default branch main (6 commit(s) ahead of v1.0.0)"; branch.Describe() != want {
+		t.Fatalf("branch = %q, want %q", branch.Describe(), want)
+	}
+
+	if _, err := classifier.Classify("octo", "action", betweenCommit); err != nil || mock.callCounts["repos/octo/action/compare/main..."+betweenCommit] != 1 {
+		t.Fatalf("expected classification to be cached, got %d calls (%v)", mock.callCounts["repos/octo/action/compare/main..."+betweenCommit], err)
+	}
+}
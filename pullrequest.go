@@ -0,0 +1,483 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+	"github.com/cli/go-gh/v2/pkg/repository"
+)
+
+// restWriter is implemented by clients that can create and update resources,
+// such as the go-gh REST client.
+type restWriter interface {
+	restClient
+	Post(path string, body io.Reader, response interface{}) error
+	Patch(path string, body io.Reader, response interface{}) error
+}
+
+// publishOptions holds the flags shared by commands that rewrite workflow
+// files and can hand the result off for review.
+type publishOptions struct {
+	CreatePR bool
+	Base     string
+	GroupBy  string
+	Commit   bool
+}
+
+func (o *publishOptions) register(fs *flag.FlagSet) {
+	o.GroupBy = groupByAll
+	fs.BoolVar(&o.CreatePR, "create-pr", false, "commit the changes to a new branch and open a pull request")
+	fs.StringVar(&o.Base, "base", "", "base branch for --create-pr (defaults to the repository's default branch)")
+	fs.BoolVar(&o.Commit, "commit", false, "stage the changed files and create a local git commit")
+	fs.Func("group-by", "split --create-pr changes by all, level, or action", func(value string) error {
+		if !validGroupStrategy(value) {
+			return fmt.Errorf("expected all, level, or action")
+		}
+		o.GroupBy = value
+		return nil
+	})
+}
+
+// validate runs the checks that must pass before any file is rewritten. No
+// file has changed yet, so with --commit anything already staged is unrelated.
+func (o *publishOptions) validate(files []*WorkflowFile) error {
+	if o.Commit {
+		return checkStagedFiles(newGitRunner(""), "", changedFiles(files))
+	}
+	return nil
+}
+
+// publish commits the changed files and opens pull requests for them when
+// requested. It is a no-op when nothing changed or no publishing flag was
+// given.
+func (o *publishOptions) publish(client restClient, files []*WorkflowFile, command string) error {
+	changes := changedFiles(files)
+	if len(changes) == 0 {
+		return nil
+	}
+
+	if o.Commit {
+		sha, err := commitChanges(newGitRunner(""), "", files)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Committed changes as %s.\n", shortSHA(sha))
+	}
+
+	if !o.CreatePR {
+		return nil
+	}
+
+	writer, ok := client.(restWriter)
+	if !ok {
+		return fmt.Errorf("--create-pr requires a client that can write to the GitHub API")
+	}
+
+	repo, err := repository.Current()
+	if err != nil {
+		return fmt.Errorf("failed to determine current repository: %w", err)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	return publishPullRequests(writer, repo.Owner, repo.Name, command, changes, o.Base, o.GroupBy, cfg.Groups)
+}
+
+// publishPullRequests opens one pull request per change group. A group whose
+// open pull request already proposes the same changes is skipped, and one
+// whose open pull request proposes older changes, such as a previous release,
+// is updated in place.
+func publishPullRequests(client restWriter, owner, repo, command string, files []*WorkflowFile, base, strategy string, configured []GroupConfig) error {
+	open, err := openPullRequestBranches(client, owner, repo)
+	if err != nil {
+		return fmt.Errorf("failed to list open pull requests: %w", err)
+	}
+
+	for _, group := range groupChanges(files, strategy, configured) {
+		groupFiles := filesForGroup(files, group)
+		bumps := summarizeChanges(groupFiles)
+
+		prefix := command
+		if group.Name != "" {
+			prefix = fmt.Sprintf("%s-%s", command, group.Name)
+		}
+		branch := changeBranchName(prefix, bumps)
+		spec := pullRequestSpec{
+			Owner:  owner,
+			Repo:   repo,
+			Base:   base,
+			Branch: branch,
+			Title:  changeTitle(group.Name, bumps),
+			Body:   changeBody(bumps),
+			Files:  groupFiles,
+		}
+
+		if existing, ok := open[branch]; ok {
+			fmt.Printf("Pull request #%d already proposes %s; skipping.\n", existing.Number, branch)
+			continue
+		}
+		if existing := findGroupPullRequest(open, branch); existing != nil {
+			if existing.Body == spec.Body {
+				fmt.Printf("Pull request #%d already proposes these changes; skipping.\n", existing.Number)
+				continue
+			}
+			spec.Branch = existing.Head.Ref
+			spec.Base = existing.Base.Ref
+			if err := updatePullRequest(client, spec, existing.Number); err != nil {
+				return err
+			}
+			fmt.Printf("Updated pull request #%d: %s\n", existing.Number, existing.HTMLURL)
+			continue
+		}
+
+		pr, err := createPullRequest(client, spec)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Opened pull request #%d: %s\n", pr.Number, pr.HTMLURL)
+	}
+	return nil
+}
+
+// findGroupPullRequest returns the open pull request whose branch belongs to
+// the same group as branch but proposes different changes, or nil.
+func findGroupPullRequest(open map[string]*openPullRequest, branch string) *openPullRequest {
+	group := branchGroup(branch)
+	var found *openPullRequest
+	for head, pull := range open {
+		if head != branch && branchGroup(head) == group && (found == nil || pull.Number > found.Number) {
+			found = pull
+		}
+	}
+	return found
+}
+
+// branchGroup strips the content hash from a branch named by
+// changeBranchName, leaving the part shared by every run for the group.
+func branchGroup(branch string) string {
+	i := strings.LastIndex(branch, "-")
+	if !strings.HasPrefix(branch, "actions-versions/") || i < 0 || len(branch)-i-1 != 8 {
+		return ""
+	}
+	return branch[:i]
+}
+
+func changedFiles(files []*WorkflowFile) []*WorkflowFile {
+	var result []*WorkflowFile
+	for _, file := range files {
+		if file.changed {
+			result = append(result, file)
+		}
+	}
+	return result
+}
+
+// actionBump summarizes every rewrite of one action from one ref to another.
+type actionBump struct {
+	Action    string
+	FromTag   string
+	ToTag     string
+	FromRef   string
+	ToRef     string
+	Locations []string
+}
+
+func summarizeChanges(files []*WorkflowFile) []*actionBump {
+	bumps := make(map[string]*actionBump)
+	var order []string
+	for _, file := range files {
+		for _, change := range file.changes {
+			usage := change.Usage
+			fromTag, _ := splitComment(change.OldComment)
+			toTag, _ := splitComment(usage.Comment)
+			key := strings.ToLower(fmt.Sprintf("%s|%s|%s|%s|%s", usage.Spec.FullPath(), fromTag, change.OldRef, toTag, usage.Ref))
+			bump, ok := bumps[key]
+			if !ok {
+				bump = &actionBump{
+					Action:  usage.Spec.FullPath(),
+					FromTag: fromTag,
+					ToTag:   toTag,
+					FromRef: change.OldRef,
+					ToRef:   usage.Ref,
+				}
+				bumps[key] = bump
+				order = append(order, key)
+			}
+			bump.Locations = append(bump.Locations, fmt.Sprintf("%s:%d", file.Path, usage.LineNumber()))
+		}
+	}
+
+	sort.Strings(order)
+	result := make([]*actionBump, 0, len(order))
+	for _, key := range order {
+		result = append(result, bumps[key])
+	}
+	return result
+}
+
+func (b *actionBump) fromLabel() string {
+	if b.FromTag != "" {
+		return b.FromTag
+	}
+	return refLabel(b.FromRef)
+}
+
+func (b *actionBump) toLabel() string {
+	if b.ToTag != "" {
+		return b.ToTag
+	}
+	return refLabel(b.ToRef)
+}
+
+func refLabel(ref string) string {
+	if isFullCommitSHA(ref) {
+		return shortSHA(ref)
+	}
+	return ref
+}
+
+func changeTitle(group string, bumps []*actionBump) string {
+	if len(bumps) == 1 {
+		bump := bumps[0]
+		if strings.EqualFold(bump.fromLabel(), bump.toLabel()) || bump.FromTag == "" {
+			return fmt.Sprintf("Pin %s to %s", bump.Action, bump.toLabel())
+		}
+		return fmt.Sprintf("Bump %s from %s to %s", bump.Action, bump.fromLabel(), bump.toLabel())
+	}
+	actions := make(map[string]struct{})
+	for _, bump := range bumps {
+		actions[strings.ToLower(bump.Action)] = struct{}{}
+	}
+	if group != "" {
+		return fmt.Sprintf("Update %d GitHub Actions in the %s group", len(actions), group)
+	}
+	return fmt.Sprintf("Update %d GitHub Actions", len(actions))
+}
+
+func changeBody(bumps []*actionBump) string {
+	var b strings.Builder
+	b.WriteString("This pull request was generated by `gh actions-versions`.\n\n")
+	b.WriteString("| Action | From | To |\n")
+	b.WriteString("| --- | --- | --- |\n")
+	for _, bump := range bumps {
+		fmt.Fprintf(&b, "| `%s` | %s (`%s`) | %s (`%s`) |\n",
+			bump.Action, bump.fromLabel(), refLabel(bump.FromRef), bump.toLabel(), refLabel(bump.ToRef))
+	}
+	return b.String()
+}
+
+// changeBranchName derives a stable branch name from the set of bumps so the
+// same run produces the same branch.
+func changeBranchName(prefix string, bumps []*actionBump) string {
+	hash := sha256.New()
+	for _, bump := range bumps {
+		fmt.Fprintf(hash, "%s@%s\n", strings.ToLower(bump.Action), strings.ToLower(bump.ToRef))
+	}
+	return fmt.Sprintf("actions-versions/%s-%s", prefix, hex.EncodeToString(hash.Sum(nil))[:8])
+}
+
+type pullRequestSpec struct {
+	Owner  string
+	Repo   string
+	Base   string
+	Branch string
+	Title  string
+	Body   string
+	Files  []*WorkflowFile
+}
+
+type pullRequest struct {
+	Number  int    `json:"number"`
+	HTMLURL string `json:"html_url"`
+}
+
+// createPullRequest commits the files to a new branch and opens a pull request
+// for it. A branch left over from a closed pull request is moved to the new
+// commit.
+func createPullRequest(client restWriter, spec pullRequestSpec) (*pullRequest, error) {
+	repoPath := fmt.Sprintf("repos/%s/%s", spec.Owner, spec.Repo)
+
+	base, commit, err := commitToBase(client, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	err = postJSON(client, repoPath+"/git/refs", map[string]string{
+		"ref": "refs/heads/" + spec.Branch,
+		"sha": commit,
+	}, nil)
+	var httpErr *api.HTTPError
+	if errors.As(err, &httpErr) && httpErr.StatusCode == 422 {
+		err = moveBranch(client, repoPath, spec.Branch, commit)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create branch %s: %w", spec.Branch, err)
+	}
+
+	var pr pullRequest
+	err = postJSON(client, repoPath+"/pulls", map[string]string{
+		"title": spec.Title,
+		"head":  spec.Branch,
+		"base":  base,
+		"body":  spec.Body,
+	}, &pr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pull request: %w", err)
+	}
+	return &pr, nil
+}
+
+// updatePullRequest replaces the commit on an open pull request's branch with
+// the files committed onto its base, and refreshes its title and body.
+func updatePullRequest(client restWriter, spec pullRequestSpec, number int) error {
+	repoPath := fmt.Sprintf("repos/%s/%s", spec.Owner, spec.Repo)
+
+	_, commit, err := commitToBase(client, spec)
+	if err != nil {
+		return err
+	}
+	if err := moveBranch(client, repoPath, spec.Branch, commit); err != nil {
+		return fmt.Errorf("failed to update branch %s: %w", spec.Branch, err)
+	}
+
+	err = patchJSON(client, fmt.Sprintf("%s/pulls/%d", repoPath, number), map[string]string{
+		"title": spec.Title,
+		"body":  spec.Body,
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to update pull request #%d: %w", number, err)
+	}
+	return nil
+}
+
+// moveBranch force-updates an existing branch to point at commit.
+func moveBranch(client restWriter, repoPath, branch, commit string) error {
+	pathRef := strings.ReplaceAll(url.PathEscape(branch), "%2F", "/")
+	return patchJSON(client, fmt.Sprintf("%s/git/refs/heads/%s", repoPath, pathRef), map[string]interface{}{
+		"sha":   commit,
+		"force": true,
+	}, nil)
+}
+
+// commitToBase commits the files on top of the base branch via the git data
+// API (blobs, a tree and a commit), returning the base branch name and the
+// new commit's SHA.
+func commitToBase(client restWriter, spec pullRequestSpec) (string, string, error) {
+	repoPath := fmt.Sprintf("repos/%s/%s", spec.Owner, spec.Repo)
+
+	base := spec.Base
+	if base == "" {
+		var repoInfo struct {
+			DefaultBranch string `json:"default_branch"`
+		}
+		if err := client.Get(repoPath, &repoInfo); err != nil {
+			return "", "", fmt.Errorf("failed to look up default branch: %w", err)
+		}
+		base = repoInfo.DefaultBranch
+	}
+
+	var baseRef struct {
+		Object struct {
+			SHA string `json:"sha"`
+		} `json:"object"`
+	}
+	pathRef := strings.ReplaceAll(url.PathEscape(base), "%2F", "/")
+	if err := client.Get(fmt.Sprintf("%s/git/ref/heads/%s", repoPath, pathRef), &baseRef); err != nil {
+		return "", "", fmt.Errorf("failed to look up base branch %s: %w", base, err)
+	}
+
+	var baseCommit struct {
+		Tree struct {
+			SHA string `json:"sha"`
+		} `json:"tree"`
+	}
+	if err := client.Get(fmt.Sprintf("%s/git/commits/%s", repoPath, baseRef.Object.SHA), &baseCommit); err != nil {
+		return "", "", fmt.Errorf("failed to look up base commit: %w", err)
+	}
+
+	type treeEntry struct {
+		Path string `json:"path"`
+		Mode string `json:"mode"`
+		Type string `json:"type"`
+		SHA  string `json:"sha"`
+	}
+	var entries []treeEntry
+	for _, file := range spec.Files {
+		var blob struct {
+			SHA string `json:"sha"`
+		}
+		err := postJSON(client, repoPath+"/git/blobs", map[string]string{
+			"content":  string(file.Content()),
+			"encoding": "utf-8",
+		}, &blob)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to create blob for %s: %w", file.Path, err)
+		}
+		mode := "100644"
+		if info, err := os.Stat(file.Path); err == nil && info.Mode().Perm()&0o111 != 0 {
+			mode = "100755"
+		}
+		entries = append(entries, treeEntry{
+			Path: filepath.ToSlash(filepath.Clean(file.Path)),
+			Mode: mode,
+			Type: "blob",
+			SHA:  blob.SHA,
+		})
+	}
+
+	var tree struct {
+		SHA string `json:"sha"`
+	}
+	err := postJSON(client, repoPath+"/git/trees", map[string]interface{}{
+		"base_tree": baseCommit.Tree.SHA,
+		"tree":      entries,
+	}, &tree)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create tree: %w", err)
+	}
+
+	var commit struct {
+		SHA string `json:"sha"`
+	}
+	err = postJSON(client, repoPath+"/git/commits", map[string]interface{}{
+		"message": spec.Title,
+		"tree":    tree.SHA,
+		"parents": []string{baseRef.Object.SHA},
+	}, &commit)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create commit: %w", err)
+	}
+
+	return base, commit.SHA, nil
+}
+
+func postJSON(client restWriter, path string, payload interface{}, response interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return client.Post(path, bytes.NewReader(body), response)
+}
+
+func patchJSON(client restWriter, path string, payload interface{}, response interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return client.Patch(path, bytes.NewReader(body), response)
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+)
+
+// fakeGitHub is a minimal in-process stand-in for the git data and pulls
+// endpoints used when opening pull requests.
+type fakeGitHub struct {
+	t        *testing.T
+	mu       sync.Mutex
+	blobs    map[string]string
+	trees    []map[string]interface{}
+	commits  []map[string]interface{}
+	refs     map[string]string
+	pulls    []map[string]interface{}
+	nextID   int
+	server   *httptest.Server
+	baseTree string
+}
+
+func newFakeGitHub(t *testing.T) *fakeGitHub {
+	t.Helper()
+	f := &fakeGitHub{
+		t:        t,
+		blobs:    make(map[string]string),
+		refs:     map[string]string{"refs/heads/main": "base-commit"},
+		baseTree: "base-tree",
+	}
+	f.server = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func (f *fakeGitHub) client() *api.RESTClient {
+	f.t.Helper()
+	target, _ := url.Parse(f.server.URL)
+	client, err := api.NewRESTClient(api.ClientOptions{
+		Host:      "github.localhost",
+		AuthToken: "test-token",
+		Transport: rewriteTransport{target: target},
+	})
+	if err != nil {
+		f.t.Fatalf("failed to create REST client: %v", err)
+	}
+	return client
+}
+
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (rt rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = rt.target.Scheme
+	req.URL.Host = rt.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func (f *fakeGitHub) id(prefix string) string {
+	f.nextID++
+	return fmt.Sprintf("%s-%d", prefix, f.nextID)
+}
+
+func (f *fakeGitHub) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var payload map[string]interface{}
+	if r.Body != nil && (r.Method == http.MethodPost || r.Method == http.MethodPatch) {
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/repos/octo/repo")
+	respond := func(status int, body interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(body)
+	}
+
+	switch {
+	case r.Method == http.MethodGet && path == "":
+		respond(200, map[string]string{"default_branch": "main"})
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "/git/ref/heads/"):
+		sha, ok := f.refs["refs/heads/"+strings.TrimPrefix(path, "/git/ref/heads/")]
+		if !ok {
+			respond(404, map[string]string{"message": "Not Found"})
+			return
+		}
+		respond(200, map[string]interface{}{"object": map[string]string{"sha": sha, "type": "commit"}})
+	case r.Method == http.MethodGet && path == "/git/commits/base-commit":
+		respond(200, map[string]interface{}{"tree": map[string]string{"sha": f.baseTree}})
+	case r.Method == http.MethodPost && path == "/git/blobs":
+		sha := f.id("blob")
+		f.blobs[sha] = payload["content"].(string)
+		respond(201, map[string]string{"sha": sha})
+	case r.Method == http.MethodPost && path == "/git/trees":
+		f.trees = append(f.trees, payload)
+		respond(201, map[string]string{"sha": f.id("tree")})
+	case r.Method == http.MethodPost && path == "/git/commits":
+		f.commits = append(f.commits, payload)
+		respond(201, map[string]string{"sha": f.id("commit")})
+	case r.Method == http.MethodPost && path == "/git/refs":
+		ref := payload["ref"].(string)
+		if _, exists := f.refs[ref]; exists {
+			respond(422, map[string]string{"message": "Reference already exists"})
+			return
+		}
+		f.refs[ref] = payload["sha"].(string)
+		respond(201, map[string]string{"ref": ref})
+	case r.Method == http.MethodPatch && strings.HasPrefix(path, "/git/refs/heads/"):
+		ref := strings.TrimPrefix(path, "/git/")
+		if _, exists := f.refs[ref]; !exists || payload["force"] != true {
+			respond(422, map[string]string{"message": "Reference cannot be updated"})
+			return
+		}
+		f.refs[ref] = payload["sha"].(string)
+		respond(200, map[string]string{"ref": ref})
+	case r.Method == http.MethodPatch && strings.HasPrefix(path, "/pulls/"):
+		var number int
+		fmt.Sscanf(strings.TrimPrefix(path, "/pulls/"), "%d", &number)
+		if number < 1 || number > len(f.pulls) {
+			respond(404, map[string]string{"message": "Not Found"})
+			return
+		}
+		f.pulls[number-1]["title"] = payload["title"]
+		f.pulls[number-1]["body"] = payload["body"]
+		respond(200, f.pulls[number-1])
+	case r.Method == http.MethodGet && path == "/pulls":
+		if r.URL.Query().Get("state") != "open" {
+			f.t.Errorf("expected only open pull requests to be listed, got %s", r.URL.RawQuery)
+		}
+		respond(200, f.pulls)
+	case r.Method == http.MethodPost && path == "/pulls":
+		number := len(f.pulls) + 1
+		pr := map[string]interface{}{
+			"number":   number,
+			"html_url": fmt.Sprintf("https://github.com/octo/repo/pull/%d", number),
+			"title":    payload["title"],
+			"body":     payload["body"],
+			"base":     map[string]interface{}{"ref": payload["base"]},
+			"head":     map[string]interface{}{"ref": payload["head"]},
+		}
+		f.pulls = append(f.pulls, pr)
+		respond(201, pr)
+	default:
+		f.t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+		respond(404, map[string]string{"message": "Not Found"})
+	}
+}
+
+func TestCreatePullRequest(t *testing.T) {
+	t.Parallel()
+	const oldCommit = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	const newCommit = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+
+	wf := buildWorkflowFile(t, `      - uses: actions/checkout@`+oldCommit+` # v4.1.0`)
+	wf.Path = ".github/workflows/ci.yml"
+	wf.Uses[0].Set(newCommit, "v5.0.0")
+
+	bumps := summarizeChanges([]*WorkflowFile{wf})
+	if len(bumps) != 1 {
+		t.Fatalf("expected 1 bump, got %d", len(bumps))
+	}
+	title := changeTitle("", bumps)
+	if title != "Bump actions/checkout from v4.1.0 to v5.0.0" {
+		t.Fatalf("unexpected title %q", title)
+	}
+	body := changeBody(bumps)
+	if !strings.Contains(body, "| `actions/checkout` | v4.1.0 (`aaaaaaaaaaaa`) | v5.0.0 (`bbbbbbbbbbbb`) |") {
+		t.Fatalf("unexpected body:\n%s", body)
+	}
+
+	gh := newFakeGitHub(t)
+	branch := changeBranchName("upgrade", bumps)
+	pr, err := createPullRequest(gh.client(), pullRequestSpec{
+		Owner:  "octo",
+		Repo:   "repo",
+		Branch: branch,
+		Title:  title,
+		Body:   body,
+		Files:  []*WorkflowFile{wf},
+	})
+	if err != nil {
+		t.Fatalf("createPullRequest error: %v", err)
+	}
+	if pr.Number != 1 || pr.HTMLURL != "https://github.com/octo/repo/pull/1" {
+		t.Fatalf("unexpected pull request %+v", pr)
+	}
+
+	if len(gh.trees) != 1 || gh.trees[0]["base_tree"] != "base-tree" {
+		t.Fatalf("unexpected trees: %+v", gh.trees)
+	}
+	entries := gh.trees[0]["tree"].([]interface{})
+	entry := entries[0].(map[string]interface{})
+	if entry["path"] != ".github/workflows/ci.yml" || entry["mode"] != "100644" {
+		t.Fatalf("unexpected tree entry: %+v", entry)
+	}
+	content := gh.blobs[entry["sha"].(string)]
+	if !strings.Contains(content, newCommit+" # v5.0.0") {
+		t.Fatalf("blob does not contain the rewritten line:\n%s", content)
+	}
+	if parents := gh.commits[0]["parents"].([]interface{}); parents[0] != "base-commit" {
+		t.Fatalf("unexpected commit parents: %v", parents)
+	}
+	if gh.refs["refs/heads/"+branch] == "" {
+		t.Fatalf("branch %s was not created", branch)
+	}
+	if gh.pulls[0]["base"].(map[string]interface{})["ref"] != "main" {
+		t.Fatalf("pull request should target the default branch: %+v", gh.pulls[0])
+	}
+
+	// A branch left over from a closed pull request is reused.
+	previous := gh.refs["refs/heads/"+branch]
+	if _, err := createPullRequest(gh.client(), pullRequestSpec{
+		Owner: "octo", Repo: "repo", Branch: branch, Title: title, Files: []*WorkflowFile{wf},
+	}); err != nil {
+		t.Fatalf("expected an existing branch to be reused, got %v", err)
+	}
+	if ref := gh.refs["refs/heads/"+branch]; ref == previous || ref == "" {
+		t.Fatalf("expected branch %s to move to the new commit, still at %s", branch, ref)
+	}
+}
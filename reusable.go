@@ -0,0 +1,124 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+)
+
+// maxReusableWorkflowDepth is how many levels of nested reusable workflows
+// GitHub allows a caller to reach, and so how deep checks descend.
+const maxReusableWorkflowDepth = 10
+
+// reusableWorkflowChecker reads remote reusable workflows at their pinned
+// refs and descends into the jobs they call, caching each file by ref.
+type reusableWorkflowChecker struct {
+	client   restClient
+	policy   Policy
+	contents map[string]*WorkflowFile
+	errors   map[string]error
+}
+
+func newReusableWorkflowChecker(client restClient, policy Policy) *reusableWorkflowChecker {
+	return &reusableWorkflowChecker{
+		client:   client,
+		policy:   policy,
+		contents: make(map[string]*WorkflowFile),
+		errors:   make(map[string]error),
+	}
+}
+
+func (c *reusableWorkflowChecker) fetch(spec ActionSpec, ref string) (*WorkflowFile, error) {
+	key := strings.ToLower(fmt.Sprintf("%s@%s", spec.FullPath(), ref))
+	if file, ok := c.contents[key]; ok {
+		return file, nil
+	}
+	if err, ok := c.errors[key]; ok {
+		return nil, err
+	}
+	content, err := fetchFileContent(c.client, spec.Owner, spec.Repo, spec.Path, ref)
+	if err != nil {
+		c.errors[key] = err
+		return nil, err
+	}
+	file := newWorkflowFile(spec.FullPath(), content)
+	c.contents[key] = file
+	return file, nil
+}
+
+// Check confirms the reusable workflow a usage calls exists at its pinned
+// commit, then reports unpinned actions and workflows called from its jobs,
+// transitively. A missing file is an error; findings inside the called
+// workflows are warnings, since they can only be fixed upstream.
+func (c *reusableWorkflowChecker) Check(usage *ActionUsage) []Issue {
+	issue := func(severity issueSeverity, message string) Issue {
+		return Issue{File: usage.File.Path, Line: usage.LineNumber(), Message: message, Severity: severity}
+	}
+
+	called, err := c.fetch(usage.Spec, usage.Ref)
+	if err != nil {
+		var httpErr *api.HTTPError
+		if errors.As(err, &httpErr) && httpErr.StatusCode == 404 {
+			return []Issue{issue(severityError, fmt.Sprintf("uses %s, which does not exist at %s", usage.Spec.Describe(), shortSHA(usage.Ref)))}
+		}
+		return []Issue{issue(severityWarning, fmt.Sprintf("unable to read %s at %s: %v", usage.Spec.Describe(), shortSHA(usage.Ref), err))}
+	}
+
+	var issues []Issue
+	visited := map[string]bool{strings.ToLower(reusableCallName(usage)): true}
+	c.walk(called, []*ActionUsage{usage}, visited,
+		func(calls []*ActionUsage, nested *ActionUsage) {
+			if !isFullCommitSHA(nested.Ref) && !c.policy.Trusted(nested.Spec) {
+				issues = append(issues, issue(severityWarning, fmt.Sprintf("%s calls %s, which is not pinned to a full commit SHA",
+					reusableVia(calls), reusableCallName(nested))))
+			}
+		},
+		func(calls []*ActionUsage, nested *ActionUsage, err error) {
+			issues = append(issues, issue(severityWarning, fmt.Sprintf("%s calls %s, which could not be read: %v",
+				reusableVia(calls), reusableCallName(nested), err)))
+		})
+	return issues
+}
+
+// walk visits every usage in a called reusable workflow and descends into
+// the reusable workflows it calls, up to GitHub's nesting limit. calls lists
+// the reusable workflow usages, outermost first, that lead to called; failed
+// is told about nested workflows that could not be read.
+func (c *reusableWorkflowChecker) walk(called *WorkflowFile, calls []*ActionUsage, visited map[string]bool,
+	visit func(calls []*ActionUsage, nested *ActionUsage), failed func(calls []*ActionUsage, nested *ActionUsage, err error)) {
+	for _, nested := range called.Uses {
+		visit(calls, nested)
+		if !nested.Spec.IsReusableWorkflow() || len(calls) >= maxReusableWorkflowDepth {
+			continue
+		}
+		key := strings.ToLower(reusableCallName(nested))
+		if visited[key] {
+			continue
+		}
+
+		file, err := c.fetch(nested.Spec, nested.Ref)
+		if err != nil {
+			failed(calls, nested, err)
+			continue
+		}
+		visited[key] = true
+		c.walk(file, append(calls[:len(calls):len(calls)], nested), visited, visit, failed)
+		delete(visited, key)
+	}
+}
+
+// reusableCallName names a called action or workflow as path@ref.
+func reusableCallName(usage *ActionUsage) string {
+	return fmt.Sprintf("%s@%s", usage.Spec.FullPath(), refLabel(usage.Ref))
+}
+
+// reusableVia describes a chain of reusable workflow calls for messages.
+func reusableVia(calls []*ActionUsage) string {
+	names := make([]string, len(calls))
+	for i, call := range calls {
+		names[i] = reusableCallName(call)
+	}
+	return "reusable workflow " + strings.Join(names, " -> ")
+}
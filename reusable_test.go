@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+)
+
+func TestActionSpecIsReusableWorkflow(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		path     string
+		workflow bool
+	}{
+		{".github/workflows/build.yml", true},
+		{".github/workflows/build.yaml", true},
+		{"", false},
+		{"setup", false},
+		{".github/actions/setup", false},
+		{"workflows/build.yml", false},
+	}
+	for _, tc := range cases {
+		spec := ActionSpec{Owner: "octo", Repo: "ci", Path: tc.path}
+		if got := spec.IsReusableWorkflow(); got != tc.workflow {
+			t.Fatalf("IsReusableWorkflow(%q) = %v, want %v", tc.path, got, tc.workflow)
+		}
+	}
+}
+
+func TestReusableWorkflowChecker(t *testing.T) {
+	t.Parallel()
+	const buildCommit = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	const innerCommit = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	const goneCommit = "cccccccccccccccccccccccccccccccccccccccc"
+
+	mock := newMockRESTClient(t).
+		withFile("octo/ci", ".github/workflows/build.yml", buildCommit, strings.Join([]string{
+			"on: workflow_call",
+			"jobs:",
+			"  build:",
+			"    steps:",
+			"      - uses: actions/checkout@v4",
+			"      - uses: my-org/setup@main",
+			"  inner:",
+			"    uses: octo/ci/.github/workflows/inner.yml@" + innerCommit,
+		}, "\n")).
+		withFile("octo/ci", ".github/workflows/inner.yml", innerCommit, strings.Join([]string{
+			"on: workflow_call",
+			"jobs:",
+			"  again:",
+			"    uses: octo/ci/.github/workflows/build.yml@" + buildCommit,
+			"  test:",
+			"    steps:",
+			"      - uses: actions/setup-node@v4",
+		}, "\n")).
+		withError(fmt.Sprintf("repos/octo/ci/contents/.github/workflows/gone.yml?ref=%s", goneCommit),
+			&api.HTTPError{StatusCode: 404, RequestURL: &url.URL{Path: "repos/octo/ci/contents/.github/workflows/gone.yml"}})
+
+	wf := buildWorkflowFileFromLines(t,
+		"    uses: octo/ci/.github/workflows/build.yml@"+buildCommit+" # v1.0.0",
+		"    uses: octo/ci/.github/workflows/gone.yml@"+goneCommit+" # v1.0.0",
+	)
+	if wf.Uses[0].Kind() != usageKindReusableWorkflow {
+		t.Fatalf("expected a reusable workflow usage, got %q", wf.Uses[0].Kind())
+	}
+
+	checker := newReusableWorkflowChecker(mock, Policy{TrustedOwners: []string{"my-org"}})
+
+	var messages []string
+	for _, usage := range wf.Uses {
+		for _, issue := range checker.Check(usage) {
+			severity := "error"
+			if issue.Severity == severityWarning {
+				severity = "warning"
+			}
+			messages = append(messages, fmt.Sprintf("%d %s: %s", issue.Line, severity, issue.Message))
+		}
+	}
+
+	expected := []string{
+		"1 warning: reusable workflow octo/ci/.github/workflows/build.yml@aaaaaaaaaaaa calls actions/checkout@v4, which is not pinned to a full commit SHA",
+		"1 warning: reusable workflow octo/ci/.github/workflows/build.yml@aaaaaaaaaaaa -> octo/ci/.github/workflows/inner.yml@bbbbbbbbbbbb calls actions/setup-node@v4, which is not pinned to a full commit SHA",
+		"2 error: uses reusable workflow octo/ci/.github/workflows/gone.yml, which does not exist at cccccccccccc",
+	}
+	if strings.Join(messages, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("unexpected issues:\n%s\nwant:\n%s", strings.Join(messages, "\n"), strings.Join(expected, "\n"))
+	}
+
+	// Each workflow is read once even when checked again.
+	checker.Check(wf.Uses[0])
+	if calls := mock.callCounts[fmt.Sprintf("repos/octo/ci/contents/.github/workflows/inner.yml?ref=%s", innerCommit)]; calls != 1 {
+		t.Fatalf("expected inner.yml to be fetched once, got %d", calls)
+	}
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+)
+
+// deprecatedRuntimes lists the runs.using values GitHub has deprecated or
+// stopped supporting on hosted runners.
+var deprecatedRuntimes = map[string]bool{
+	"node12": true,
+	"node16": true,
+}
+
+func isDeprecatedRuntime(using string) bool {
+	return deprecatedRuntimes[strings.ToLower(strings.TrimSpace(using))]
+}
+
+type runtimeEntry struct {
+	Action          string   `json:"action"`
+	Ref             string   `json:"ref"`
+	Version         string   `json:"version,omitempty"`
+	Using           string   `json:"using"`
+	Deprecated      bool     `json:"deprecated"`
+	Suggested       string   `json:"suggested,omitempty"`
+	SuggestedUsing  string   `json:"suggestedUsing,omitempty"`
+	SuggestedCommit string   `json:"suggestedCommit,omitempty"`
+	Locations       []string `json:"locations"`
+}
+
+func cmdRuntimes(args []string) int {
+	client, err := api.DefaultRESTClient()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create GitHub client: %v\n", err)
+		return 1
+	}
+
+	files, err := loadWorkflowFiles()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load workflow files: %v\n", err)
+		return 1
+	}
+
+	if len(allUsages(files)) == 0 {
+		fmt.Println("No workflow or composite action usages found.")
+		return 0
+	}
+
+	exit := runRuntimes(os.Stdout, client, files, args)
+	return exit
+}
+
+func runRuntimes(w io.Writer, client restClient, files []*WorkflowFile, args []string) int {
+	fs := flag.NewFlagSet("runtimes", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	format := fs.String("format", "table", "output format (table, json)")
+	exitCode := fs.Bool("exit-code", false, "exit with status 1 when any action runs on a deprecated runtime")
+
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+
+	if fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "runtimes does not accept positional arguments")
+		return 1
+	}
+
+	if *format != "table" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "unknown format %q (expected table or json)\n", *format)
+		return 1
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load configuration: %v\n", err)
+		return 1
+	}
+
+	entries, warnings := collectRuntimes(client, files, cfg.TagPrefixes)
+
+	switch *format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(entries)
+	default:
+		err = writeRuntimesTable(w, entries)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write report: %v\n", err)
+		return 1
+	}
+
+	for _, warning := range warnings {
+		fmt.Fprintln(os.Stderr, warning)
+	}
+
+	if *exitCode {
+		for _, entry := range entries {
+			if entry.Deprecated {
+				return 1
+			}
+		}
+	}
+	return 0
+}
+
+// collectRuntimes reads runs.using from every referenced action at its
+// pinned ref and, for actions on a deprecated runtime, finds the oldest newer
+// release in its tag family that runs on a supported one.
+func collectRuntimes(client restClient, files []*WorkflowFile, prefixes map[string]string) ([]*runtimeEntry, []string) {
+	finder := newRuntimeFinder(client)
+	entries := make(map[string]*runtimeEntry)
+	var order []string
+	var warnings []string
+
+	for _, usage := range allUsages(files) {
+		// Reusable workflows have no runs.using of their own.
+		if usage.Kind() == usageKindReusableWorkflow {
+			continue
+		}
+		key := strings.ToLower(fmt.Sprintf("%s@%s", usage.Spec.FullPath(), usage.Ref))
+		location := fmt.Sprintf("%s:%d", usage.File.Path, usage.LineNumber())
+		if entry, ok := entries[key]; ok {
+			entry.Locations = append(entry.Locations, location)
+			continue
+		}
+
+		using, err := finder.Using(usage.Spec, usage.Ref)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s unable to read runtime of %s at %s: %v",
+				location, usage.Spec.FullPath(), refLabel(usage.Ref), err))
+			continue
+		}
+
+		version, _ := splitComment(usage.Comment)
+		if version == "" && !isFullCommitSHA(usage.Ref) {
+			version = usage.Ref
+		}
+		entry := &runtimeEntry{
+			Action:     usage.Spec.FullPath(),
+			Ref:        usage.Ref,
+			Version:    version,
+			Using:      using,
+			Deprecated: isDeprecatedRuntime(using),
+			Locations:  []string{location},
+		}
+		if entry.Deprecated {
+			var tag, tagUsing string
+			prefix, err := finder.resolver.TagPrefix(usage.Spec, prefixes)
+			if err == nil {
+				tag, tagUsing, err = finder.OldestSupported(usage.Spec, prefix, version)
+			}
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("unable to find a supported release of %s: %v", usage.Spec.FullPath(), err))
+			} else if tag != "" {
+				entry.Suggested = tag
+				entry.SuggestedUsing = tagUsing
+				if _, commit, err := finder.resolver.ResolveSpec(usage.Spec.Owner, usage.Spec.Repo, tag); err == nil {
+					entry.SuggestedCommit = commit
+				}
+			}
+		}
+		entries[key] = entry
+		order = append(order, key)
+	}
+
+	sort.Strings(order)
+	result := make([]*runtimeEntry, 0, len(order))
+	for _, key := range order {
+		result = append(result, entries[key])
+	}
+	return result, warnings
+}
+
+// runtimeFinder caches runs.using lookups per action and ref.
+type runtimeFinder struct {
+	client   restClient
+	resolver *TagResolver
+	using    map[string]string
+}
+
+func newRuntimeFinder(client restClient) *runtimeFinder {
+	return &runtimeFinder{
+		client:   client,
+		resolver: NewTagResolver(client),
+		using:    make(map[string]string),
+	}
+}
+
+func (f *runtimeFinder) Using(spec ActionSpec, ref string) (string, error) {
+	key := strings.ToLower(fmt.Sprintf("%s@%s", spec.FullPath(), ref))
+	if using, ok := f.using[key]; ok {
+		return using, nil
+	}
+	metadata, err := fetchActionMetadata(f.client, spec, ref)
+	if err != nil {
+		return "", err
+	}
+	f.using[key] = metadata.Runs.Using
+	return metadata.Runs.Using, nil
+}
+
+// OldestSupported returns the oldest release in the tag family with the given
+// prefix that is newer than current and whose action.yml uses a supported
+// runtime, or an empty tag when none does.
+// Actions do not move back to deprecated runtimes, so the releases are
+// binary searched rather than checked one by one.
+func (f *runtimeFinder) OldestSupported(spec ActionSpec, prefix, current string) (string, string, error) {
+	tags, err := f.resolver.ReleaseTags(spec.Owner, spec.Repo)
+	if err != nil {
+		return "", "", err
+	}
+
+	currentVersion, hasCurrent := parseTagVersion(current)
+	type candidate struct {
+		tag     string
+		version semver
+	}
+	var candidates []candidate
+	for _, tag := range tags {
+		if tagPrefix, _ := splitTagPrefix(tag); !strings.EqualFold(tagPrefix, prefix) {
+			continue
+		}
+		version, ok := parseTagVersion(tag)
+		if !ok || version.Prerelease != "" {
+			continue
+		}
+		if hasCurrent && version.Compare(currentVersion) <= 0 {
+			continue
+		}
+		candidates = append(candidates, candidate{tag: tag, version: version})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].version.Compare(candidates[j].version) < 0
+	})
+
+	var searchErr error
+	supported := func(i int) bool {
+		if searchErr != nil {
+			return false
+		}
+		using, err := f.Using(spec, candidates[i].tag)
+		if err != nil {
+			searchErr = err
+			return false
+		}
+		return !isDeprecatedRuntime(using)
+	}
+
+	idx := sort.Search(len(candidates), supported)
+	if searchErr != nil {
+		return "", "", searchErr
+	}
+	if idx == len(candidates) {
+		return "", "", nil
+	}
+	using, _ := f.Using(spec, candidates[idx].tag)
+	return candidates[idx].tag, using, nil
+}
+
+func writeRuntimesTable(w io.Writer, entries []*runtimeEntry) error {
+	var deprecated []*runtimeEntry
+	for _, entry := range entries {
+		if entry.Deprecated {
+			deprecated = append(deprecated, entry)
+		}
+	}
+	if len(deprecated) == 0 {
+		fmt.Fprintln(w, "All actions use supported runtimes.")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ACTION\tCURRENT\tRUNTIME\tSUGGESTED")
+	for _, entry := range deprecated {
+		current := entry.Version
+		if current == "" {
+			current = refLabel(entry.Ref)
+		}
+		suggested := "no supported release"
+		if entry.Suggested != "" {
+			suggested = fmt.Sprintf("%s (%s)", entry.Suggested, entry.SuggestedUsing)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", entry.Action, current, entry.Using, suggested)
+	}
+	return tw.Flush()
+}
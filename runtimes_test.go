@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestRunRuntimes(t *testing.T) {
+	t.Parallel()
+	const pinnedCommit = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	const fixedCommit = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+
+	mock := newMockRESTClient(t).
+		withFile("octo/legacy", "action.yml", pinnedCommit, "runs:\n  using: node16\n").
+		withFile("octo/legacy", "action.yml", "v2.1.0", "runs:\n  using: node16\n").
+		withFile("octo/legacy", "action.yml", "v2.2.0", "runs:\n  using: node24\n").
+		withFile("octo/legacy", "action.yml", "v3.0.0", "runs:\n  using: node24\n").
+		withFile("octo/legacy", "action.yml", "v4.0.0", "runs:\n  using: node24\n").
+		withFile("octo/modern", "action.yml", pinnedCommit, "runs:\n  using: composite\n").
+		withFile("octo/current", "action.yml", pinnedCommit, "runs:\n  using: node20\n").
+		withJSON("repos/octo/legacy/releases?per_page=100&page=1", []map[string]interface{}{
+			{"tag_name": "v4.0.0", "prerelease": false},
+			{"tag_name": "v3.0.0", "prerelease": false},
+			{"tag_name": "v2.2.0", "prerelease": false},
+			{"tag_name": "v2.1.0", "prerelease": false},
+			{"tag_name": "v2.0.0", "prerelease": false},
+		}).
+		withJSON("repos/octo/legacy/git/ref/tags/v2.2.0", map[string]interface{}{
+			"object": map[string]interface{}{"sha": fixedCommit, "type": "commit"},
+		})
+
+	wf := buildWorkflowFileFromLines(t,
+		"      - uses: octo/legacy@"+pinnedCommit+" # v2.0.0",
+		"      - uses: octo/modern@"+pinnedCommit+" # v1",
+		"      - uses: octo/current@"+pinnedCommit+" # v1",
+	)
+
+	var out bytes.Buffer
+	if exit := runRuntimes(&out, mock, []*WorkflowFile{wf}, []string{"--format", "json", "--exit-code"}); exit != 1 {
+		t.Fatalf("expected --exit-code to report the deprecated runtime, got %d", exit)
+	}
+
+	var entries []runtimeEntry
+	if err := json.Unmarshal(out.Bytes(), &entries); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out.String())
+	}
+	deprecated := make(map[string]runtimeEntry)
+	for _, entry := range entries {
+		if entry.Deprecated {
+			deprecated[entry.Action] = entry
+		}
+	}
+	if len(deprecated) != 1 {
+		t.Fatalf("expected only octo/legacy to be deprecated, got %+v", entries)
+	}
+	legacy := deprecated["octo/legacy"]
+	if legacy.Version != "v2.0.0" || legacy.Using != "node16" || legacy.Suggested != "v2.2.0" ||
+		legacy.SuggestedUsing != "node24" || legacy.SuggestedCommit != fixedCommit {
+		t.Fatalf("unexpected entry for octo/legacy: %+v", legacy)
+	}
+}
@@ -0,0 +1,496 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+)
+
+// sbomComponent is one action or container image in the bill of materials.
+type sbomComponent struct {
+	Kind      string
+	Name      string
+	Version   string
+	Commit    string
+	PURL      string
+	Source    string
+	Locations []string
+}
+
+const (
+	sbomKindAction    = "action"
+	sbomKindContainer = "container"
+)
+
+// containerImage is a container referenced by docker:// uses, a job
+// container, or a service.
+type containerImage struct {
+	File  *WorkflowFile
+	Line  int
+	Image string
+}
+
+func cmdSBOM(args []string) int {
+	client, err := api.DefaultRESTClient()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create GitHub client: %v\n", err)
+		return 1
+	}
+
+	files, err := loadWorkflowFiles()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load workflow files: %v\n", err)
+		return 1
+	}
+
+	exit := runSBOM(os.Stdout, client, files, args)
+	return exit
+}
+
+func runSBOM(w io.Writer, client restClient, files []*WorkflowFile, args []string) int {
+	fs := flag.NewFlagSet("sbom", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	format := fs.String("format", "cyclonedx", "document format (cyclonedx, spdx)")
+	name := fs.String("name", "", "name of the described project (defaults to the current directory)")
+
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+
+	if fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "sbom does not accept positional arguments")
+		return 1
+	}
+
+	if *format != "cyclonedx" && *format != "spdx" {
+		fmt.Fprintf(os.Stderr, "unknown format %q (expected cyclonedx or spdx)\n", *format)
+		return 1
+	}
+
+	project := *name
+	if project == "" {
+		if wd, err := os.Getwd(); err == nil {
+			project = wd[strings.LastIndexAny(wd, `/\`)+1:]
+		}
+	}
+
+	components, warnings := collectSBOMComponents(NewTagResolver(client), files)
+
+	var document interface{}
+	if *format == "spdx" {
+		document = spdxDocument(project, components)
+	} else {
+		document = cycloneDXDocument(project, components)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(document); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write SBOM: %v\n", err)
+		return 1
+	}
+
+	for _, warning := range warnings {
+		fmt.Fprintln(os.Stderr, warning)
+	}
+	return 0
+}
+
+// collectSBOMComponents lists every distinct action ref and container image,
+// resolving the version and commit of each action.
+func collectSBOMComponents(resolver *TagResolver, files []*WorkflowFile) ([]*sbomComponent, []string) {
+	components := make(map[string]*sbomComponent)
+	var order []string
+	var warnings []string
+
+	for _, usage := range allUsages(files) {
+		location := fmt.Sprintf("%s:%d", usage.File.Path, usage.LineNumber())
+		key := strings.ToLower(fmt.Sprintf("action|%s@%s", usage.Spec.FullPath(), usage.Ref))
+		if component, ok := components[key]; ok {
+			component.Locations = append(component.Locations, location)
+			continue
+		}
+
+		version, commit, err := sbomActionVersion(resolver, usage)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s %s@%s: %v",
+				location, usage.Spec.FullPath(), usage.Ref, err))
+		}
+
+		purl := fmt.Sprintf("pkg:githubactions/%s/%s@%s", strings.ToLower(usage.Spec.Owner), strings.ToLower(usage.Spec.Repo), url.PathEscape(version))
+		if usage.Spec.Path != "" {
+			purl += "#" + usage.Spec.Path
+		}
+		components[key] = &sbomComponent{
+			Kind:      sbomKindAction,
+			Name:      usage.Spec.FullPath(),
+			Version:   version,
+			Commit:    commit,
+			PURL:      purl,
+			Source:    fmt.Sprintf("https://github.com/%s/%s", usage.Spec.Owner, usage.Spec.Repo),
+			Locations: []string{location},
+		}
+		order = append(order, key)
+	}
+
+	for _, image := range containerImages(files) {
+		location := fmt.Sprintf("%s:%d", image.File.Path, image.Line+1)
+		key := "container|" + image.Image
+		if component, ok := components[key]; ok {
+			component.Locations = append(component.Locations, location)
+			continue
+		}
+		name, version, purl := imagePURL(image.Image)
+		components[key] = &sbomComponent{
+			Kind:      sbomKindContainer,
+			Name:      name,
+			Version:   version,
+			PURL:      purl,
+			Locations: []string{location},
+		}
+		order = append(order, key)
+	}
+
+	sort.Strings(order)
+	result := make([]*sbomComponent, 0, len(order))
+	for _, key := range order {
+		result = append(result, components[key])
+	}
+	return result, warnings
+}
+
+// sbomActionVersion returns the version an action usage runs and the commit
+// it resolves to. For SHA pins the version is the most specific tag on the
+// commit, falling back to the version comment and then the ref itself; tag
+// and branch refs are their own version. A failed tag lookup is returned as
+// an error alongside the fallback.
+func sbomActionVersion(resolver *TagResolver, usage *ActionUsage) (string, string, error) {
+	if !isFullCommitSHA(usage.Ref) {
+		commit, err := resolver.ResolveRef(usage.Spec.Owner, usage.Spec.Repo, usage.Ref)
+		if err != nil {
+			err = fmt.Errorf("unable to resolve ref: %w", err)
+		}
+		return usage.Ref, commit, err
+	}
+
+	tag, err := resolver.TagForCommit(usage.Spec.Owner, usage.Spec.Repo, usage.Ref)
+	if err != nil {
+		err = fmt.Errorf("unable to look up tags: %w", err)
+	}
+	if tag != "" {
+		return tag, usage.Ref, nil
+	}
+	if version, _ := splitComment(usage.Comment); version != "" {
+		return version, usage.Ref, err
+	}
+	return usage.Ref, usage.Ref, err
+}
+
+// imagePURL splits a container image reference into a name, version and
+// package URL, e.g. ghcr.io/octo/app:1.2 becomes
+// pkg:docker/octo/app@1.2?repository_url=ghcr.io.
+func imagePURL(image string) (string, string, string) {
+	name := image
+	version := "latest"
+	if at := strings.Index(name, "@"); at >= 0 {
+		name, version = name[:at], name[at+1:]
+	} else if colon := strings.LastIndex(name, ":"); colon > strings.LastIndex(name, "/") {
+		name, version = name[:colon], name[colon+1:]
+	}
+
+	registry := ""
+	if slash := strings.Index(name, "/"); slash >= 0 {
+		first := name[:slash]
+		if strings.ContainsAny(first, ".:") || first == "localhost" {
+			registry, name = first, name[slash+1:]
+		}
+	}
+	if registry == "docker.io" {
+		registry = ""
+	}
+	if registry == "" && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+
+	purl := fmt.Sprintf("pkg:docker/%s@%s", name, url.PathEscape(version))
+	if registry != "" {
+		purl += "?repository_url=" + url.QueryEscape(registry)
+		name = registry + "/" + name
+	}
+	return name, version, purl
+}
+
+// containerImages finds docker:// uses, job containers and service images.
+// Images built from expressions are skipped.
+func containerImages(files []*WorkflowFile) []containerImage {
+	var images []containerImage
+	for _, file := range files {
+		for idx, line := range file.Lines {
+			key, value, indent, ok := yamlKeyValue(line)
+			if !ok || value == "" || strings.Contains(value, "${{") {
+				continue
+			}
+			switch {
+			case key == "uses" && strings.HasPrefix(value, "docker://"):
+				value = strings.TrimPrefix(value, "docker://")
+			case key == "container":
+			case key == "image":
+				parent, parentIndent := yamlParentKey(file.Lines, idx, indent)
+				if parent != "container" {
+					if grandparent, _ := yamlParentKey(file.Lines, idx, parentIndent); grandparent != "services" {
+						continue
+					}
+				}
+			default:
+				continue
+			}
+			images = append(images, containerImage{File: file, Line: idx, Image: value})
+		}
+	}
+	return images
+}
+
+// yamlKeyValue splits a simple "key: value" line, dropping a leading list
+// marker, quotes and any trailing comment.
+func yamlKeyValue(line string) (string, string, int, bool) {
+	trimmed := strings.TrimLeft(line, " ")
+	indent := len(line) - len(trimmed)
+	trimmed = strings.TrimPrefix(trimmed, "- ")
+	colon := strings.Index(trimmed, ":")
+	if colon <= 0 || strings.HasPrefix(trimmed, "#") {
+		return "", "", 0, false
+	}
+	key := strings.TrimSpace(trimmed[:colon])
+	value, _ := splitValueAndComment(trimmed[colon+1:])
+	value = strings.Trim(value, `"'`)
+	return key, value, indent, true
+}
+
+// yamlParentKey returns the key of the nearest preceding line indented less
+// than indent, and that line's indentation.
+func yamlParentKey(lines []string, idx, indent int) (string, int) {
+	for i := idx - 1; i >= 0; i-- {
+		key, _, lineIndent, ok := yamlKeyValue(lines[i])
+		if ok && lineIndent < indent {
+			return key, lineIndent
+		}
+	}
+	return "", -1
+}
+
+func newUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "00000000-0000-4000-8000-000000000000"
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+type cycloneDXBOM struct {
+	BOMFormat    string               `json:"bomFormat"`
+	SpecVersion  string               `json:"specVersion"`
+	SerialNumber string               `json:"serialNumber"`
+	Version      int                  `json:"version"`
+	Metadata     cycloneDXMetadata    `json:"metadata"`
+	Components   []cycloneDXComponent `json:"components"`
+}
+
+type cycloneDXMetadata struct {
+	Timestamp string `json:"timestamp"`
+	Tools     struct {
+		Components []cycloneDXComponent `json:"components"`
+	} `json:"tools"`
+	Component cycloneDXComponent `json:"component"`
+}
+
+type cycloneDXComponent struct {
+	Type               string              `json:"type"`
+	BOMRef             string              `json:"bom-ref,omitempty"`
+	Name               string              `json:"name"`
+	Version            string              `json:"version,omitempty"`
+	PURL               string              `json:"purl,omitempty"`
+	Hashes             []cycloneDXHash     `json:"hashes,omitempty"`
+	ExternalReferences []cycloneDXExternal `json:"externalReferences,omitempty"`
+	Properties         []cycloneDXProperty `json:"properties,omitempty"`
+}
+
+type cycloneDXHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+type cycloneDXExternal struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+type cycloneDXProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+func cycloneDXDocument(project string, components []*sbomComponent) cycloneDXBOM {
+	bom := cycloneDXBOM{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: "urn:uuid:" + newUUID(),
+		Version:      1,
+		Components:   []cycloneDXComponent{},
+	}
+	bom.Metadata.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	bom.Metadata.Tools.Components = []cycloneDXComponent{{Type: "application", Name: "gh-actions-versions"}}
+	bom.Metadata.Component = cycloneDXComponent{Type: "application", Name: project}
+
+	refs := make(map[string]bool)
+	for _, component := range components {
+		entry := cycloneDXComponent{
+			Type:    "application",
+			BOMRef:  cycloneDXRef(component, refs),
+			Name:    component.Name,
+			Version: component.Version,
+			PURL:    component.PURL,
+		}
+		if component.Kind == sbomKindContainer {
+			entry.Type = "container"
+		}
+		if component.Commit != "" {
+			entry.Hashes = []cycloneDXHash{{Alg: "SHA-1", Content: component.Commit}}
+		}
+		if component.Source != "" {
+			entry.ExternalReferences = []cycloneDXExternal{{Type: "vcs", URL: component.Source}}
+		}
+		for _, location := range component.Locations {
+			entry.Properties = append(entry.Properties, cycloneDXProperty{Name: "gh-actions-versions:location", Value: location})
+		}
+		bom.Components = append(bom.Components, entry)
+	}
+	return bom
+}
+
+// cycloneDXRef returns a bom-ref for the component that no earlier component
+// uses. Actions are identified by their purl and commit, since usages of one
+// tag may be pinned to different commits.
+func cycloneDXRef(component *sbomComponent, used map[string]bool) string {
+	ref := component.PURL
+	if component.Commit != "" {
+		ref += "@" + component.Commit
+	}
+	unique := ref
+	for i := 2; used[unique]; i++ {
+		unique = fmt.Sprintf("%s-%d", ref, i)
+	}
+	used[unique] = true
+	return unique
+}
+
+type spdxDoc struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	Name             string            `json:"name"`
+	SPDXID           string            `json:"SPDXID"`
+	VersionInfo      string            `json:"versionInfo,omitempty"`
+	DownloadLocation string            `json:"downloadLocation"`
+	FilesAnalyzed    bool              `json:"filesAnalyzed"`
+	Checksums        []spdxChecksum    `json:"checksums,omitempty"`
+	ExternalRefs     []spdxExternalRef `json:"externalRefs,omitempty"`
+	SourceInfo       string            `json:"sourceInfo,omitempty"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+func spdxDocument(project string, components []*sbomComponent) spdxDoc {
+	doc := spdxDoc{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              project,
+		DocumentNamespace: fmt.Sprintf("https://spdx.org/spdxdocs/gh-actions-versions/%s-%s", url.PathEscape(project), newUUID()),
+		CreationInfo: spdxCreationInfo{
+			Created:  time.Now().UTC().Format(time.RFC3339),
+			Creators: []string{"Tool: gh-actions-versions"},
+		},
+		Packages: []spdxPackage{{
+			Name:             project,
+			SPDXID:           "SPDXRef-Project",
+			DownloadLocation: "NOASSERTION",
+		}},
+		Relationships: []spdxRelationship{{
+			SPDXElementID:      "SPDXRef-DOCUMENT",
+			RelationshipType:   "DESCRIBES",
+			RelatedSPDXElement: "SPDXRef-Project",
+		}},
+	}
+
+	for i, component := range components {
+		id := fmt.Sprintf("SPDXRef-Package-%d", i+1)
+		pkg := spdxPackage{
+			Name:             component.Name,
+			SPDXID:           id,
+			VersionInfo:      component.Version,
+			DownloadLocation: "NOASSERTION",
+			ExternalRefs: []spdxExternalRef{{
+				ReferenceCategory: "PACKAGE-MANAGER",
+				ReferenceType:     "purl",
+				ReferenceLocator:  component.PURL,
+			}},
+			SourceInfo: "referenced at " + strings.Join(component.Locations, ", "),
+		}
+		if component.Source != "" {
+			pkg.DownloadLocation = "git+" + component.Source
+			if component.Commit != "" {
+				pkg.DownloadLocation += "@" + component.Commit
+			}
+		}
+		if component.Commit != "" {
+			pkg.Checksums = []spdxChecksum{{Algorithm: "SHA1", ChecksumValue: component.Commit}}
+		}
+		doc.Packages = append(doc.Packages, pkg)
+		doc.Relationships = append(doc.Relationships, spdxRelationship{
+			SPDXElementID:      "SPDXRef-Project",
+			RelationshipType:   "DEPENDS_ON",
+			RelatedSPDXElement: id,
+		})
+	}
+	return doc
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRunSBOM(t *testing.T) {
+	t.Parallel()
+	const pinnedCommit = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	const releaseCommit = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	const untaggedCommit = "cccccccccccccccccccccccccccccccccccccccc"
+	const otherCommit = "dddddddddddddddddddddddddddddddddddddddd"
+
+	mock := newMockRESTClient(t).
+		withJSON("repos/octo/build/commits/release", map[string]interface{}{"sha": releaseCommit}).
+		withJSON("repos/actions/checkout/tags?per_page=100&page=1", []map[string]interface{}{
+			{"name": "v4", "commit": map[string]interface{}{"sha": pinnedCommit}},
+			{"name": "v4.1.0", "commit": map[string]interface{}{"sha": pinnedCommit}},
+		})
+
+	wf := buildWorkflowFileFromLines(t,
+		"jobs:",
+		"  test:",
+		"    container: node:20",
+		"    services:",
+		"      redis:",
+		"        image: ghcr.io/octo/redis:7",
+		"    steps:",
+		"      - uses: actions/checkout@"+pinnedCommit+" # v4",
+		"      - uses: octo/build/setup@release",
+		"      - uses: docker://alpine:3.19",
+		"      - uses: actions/checkout@"+pinnedCommit+" # v4",
+		"      - uses: actions/checkout@"+untaggedCommit+" # v4",
+		"      - uses: actions/checkout@"+otherCommit+" # v4",
+		"        with:",
+		"          image: not-a-container",
+	)
+
+	var out bytes.Buffer
+	if exit := runSBOM(&out, mock, []*WorkflowFile{wf}, []string{"--name", "demo"}); exit != 0 {
+		t.Fatalf("expected success, got %d", exit)
+	}
+
+	var bom cycloneDXBOM
+	if err := json.Unmarshal(out.Bytes(), &bom); err != nil {
+		t.Fatalf("invalid CycloneDX output: %v\n%s", err, out.String())
+	}
+	if bom.BOMFormat != "CycloneDX" || bom.Metadata.Component.Name != "demo" {
+		t.Fatalf("unexpected document header: %+v", bom)
+	}
+
+	if len(bom.Components) != 7 {
+		t.Fatalf("expected 7 components, got %d:\n%s", len(bom.Components), out.String())
+	}
+	refs := make(map[string]bool)
+	components := make(map[string]cycloneDXComponent)
+	for _, component := range bom.Components {
+		if component.BOMRef == "" || refs[component.BOMRef] {
+			t.Fatalf("expected a unique bom-ref, got %q:\n%s", component.BOMRef, out.String())
+		}
+		refs[component.BOMRef] = true
+		components[component.PURL] = component
+	}
+
+	// The pinned commit is described by its exact tag, not the comment.
+	checkout, ok := components["pkg:githubactions/actions/checkout@v4.1.0"]
+	if !ok {
+		t.Fatalf("missing checkout component:\n%s", out.String())
+	}
+	if len(checkout.Hashes) != 1 || checkout.Hashes[0].Content != pinnedCommit {
+		t.Fatalf("expected checkout hash %s, got %+v", pinnedCommit, checkout.Hashes)
+	}
+	if len(checkout.Properties) != 2 {
+		t.Fatalf("expected both checkout locations, got %+v", checkout.Properties)
+	}
+
+	// Commits without a tag fall back to the comment and share a purl.
+	var floating []string
+	for _, component := range bom.Components {
+		if component.PURL == "pkg:githubactions/actions/checkout@v4" {
+			floating = append(floating, component.Hashes[0].Content)
+		}
+	}
+	if len(floating) != 2 || floating[0] == floating[1] {
+		t.Fatalf("expected both untagged checkout commits under v4, got %v", floating)
+	}
+
+	build, ok := components["pkg:githubactions/octo/build@release#setup"]
+	if !ok || len(build.Hashes) != 1 || build.Hashes[0].Content != releaseCommit {
+		t.Fatalf("expected octo/build resolved to %s, got %+v", releaseCommit, build)
+	}
+
+	for _, purl := range []string{
+		"pkg:docker/library/node@20",
+		"pkg:docker/library/alpine@3.19",
+		"pkg:docker/octo/redis@7?repository_url=ghcr.io",
+	} {
+		if component, ok := components[purl]; !ok || component.Type != "container" {
+			t.Fatalf("missing container %s:\n%s", purl, out.String())
+		}
+	}
+}
+
+func TestRunSBOMSPDX(t *testing.T) {
+	t.Parallel()
+	const pinnedCommit = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+
+	wf := buildWorkflowFileFromLines(t,
+		"      - uses: actions/checkout@"+pinnedCommit+" # v4.1.0",
+	)
+
+	mock := newMockRESTClient(t).
+		withJSON("repos/actions/checkout/tags?per_page=100&page=1", []map[string]interface{}{
+			{"name": "v4.1.0", "commit": map[string]interface{}{"sha": pinnedCommit}},
+		})
+
+	var out bytes.Buffer
+	if exit := runSBOM(&out, mock, []*WorkflowFile{wf}, []string{"--format", "spdx", "--name", "demo"}); exit != 0 {
+		t.Fatalf("expected success, got %d", exit)
+	}
+
+	var doc spdxDoc
+	if err := json.Unmarshal(out.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid SPDX output: %v\n%s", err, out.String())
+	}
+	if doc.SPDXVersion != "SPDX-2.3" || len(doc.Packages) != 2 {
+		t.Fatalf("unexpected document: %+v", doc)
+	}
+	pkg := doc.Packages[1]
+	if pkg.ExternalRefs[0].ReferenceLocator != "pkg:githubactions/actions/checkout@v4.1.0" {
+		t.Fatalf("unexpected purl: %+v", pkg.ExternalRefs)
+	}
+	if len(pkg.Checksums) != 1 || pkg.Checksums[0].ChecksumValue != pinnedCommit {
+		t.Fatalf("unexpected checksums: %+v", pkg.Checksums)
+	}
+	if !strings.Contains(pkg.SourceInfo, "workflow.yml:1") {
+		t.Fatalf("expected the referencing location, got %q", pkg.SourceInfo)
+	}
+	if len(doc.Relationships) != 2 || doc.Relationships[1].RelatedSPDXElement != pkg.SPDXID {
+		t.Fatalf("unexpected relationships: %+v", doc.Relationships)
+	}
+}
+
+func TestCollectSBOMComponentsRefs(t *testing.T) {
+	t.Parallel()
+	const branchCommit = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	const pinnedCommit = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+
+	mock := newMockRESTClient(t).
+		withJSON("repos/octo/deploy/commits/main", map[string]interface{}{"sha": branchCommit}).
+		withError("repos/octo/cache/tags?per_page=100&page=1", errors.New("rate limited"))
+
+	wf := buildWorkflowFileFromLines(t,
+		"      - uses: octo/deploy@main",
+		"      - uses: octo/cache@"+pinnedCommit+" # v2",
+	)
+	components, warnings := collectSBOMComponents(NewTagResolver(mock), []*WorkflowFile{wf})
+	if len(components) != 2 {
+		t.Fatalf("expected 2 components, got %d", len(components))
+	}
+
+	// Components are sorted by key, so octo/cache comes first.
+	cache, deploy := components[0], components[1]
+	if deploy.Version != "main" || deploy.Commit != branchCommit {
+		t.Fatalf("expected the branch resolved to %s, got %+v", branchCommit, deploy)
+	}
+	if cache.Version != "v2" || cache.Commit != pinnedCommit {
+		t.Fatalf("expected the comment as a fallback version, got %+v", cache)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "unable to look up tags: rate limited") {
+		t.Fatalf("expected a tag lookup warning, got %v", warnings)
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// splitTagPrefix splits a path-scoped tag such as setup-foo/v1.2.0 or foo@v2,
+// as published by repositories hosting several actions, into its prefix
+// ("setup-foo/", "foo@") and version. Plain version tags have no prefix.
+func splitTagPrefix(tag string) (string, string) {
+	if i := strings.LastIndexAny(tag, "/@"); i >= 0 && i < len(tag)-1 {
+		if _, ok := parseSemver(tag[i+1:]); ok {
+			return tag[:i+1], tag[i+1:]
+		}
+	}
+	return "", tag
+}
+
+// parseTagVersion parses the version of a tag, ignoring any path-scoped
+// prefix.
+func parseTagVersion(tag string) (semver, bool) {
+	_, version := splitTagPrefix(tag)
+	return parseSemver(version)
+}
+
+// withTagPrefix scopes a version spec to a tag family. Specs that already
+// carry a prefix, and empty prefixes, leave the spec unchanged.
+func withTagPrefix(spec, prefix string) string {
+	if prefix == "" {
+		return spec
+	}
+	if existing, _ := splitTagPrefix(spec); existing != "" {
+		return spec
+	}
+	return prefix + spec
+}
+
+// tagPrefixCandidates lists the prefixes a sub-path action may publish under:
+// its full path or its last path element, followed by "/" or "@".
+func tagPrefixCandidates(path string) []string {
+	names := []string{path}
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		names = append(names, path[i+1:])
+	}
+	var candidates []string
+	for _, name := range names {
+		candidates = append(candidates, name+"/", name+"@")
+	}
+	return candidates
+}
+
+// TagPrefix returns the tag family for an action: the prefix configured for
+// its owner/repo/path in tag-prefixes, or one detected from the repository's
+// release tags for actions in a sub-path. Actions at the repository root, and
+// sub-path actions whose repository has no matching tags, use plain tags.
+func (r *TagResolver) TagPrefix(spec ActionSpec, configured map[string]string) (string, error) {
+	for action, prefix := range configured {
+		if strings.EqualFold(action, spec.FullPath()) {
+			return prefix, nil
+		}
+	}
+	if spec.Path == "" || spec.IsReusableWorkflow() {
+		return "", nil
+	}
+
+	cacheKey := strings.ToLower(spec.FullPath())
+	if prefix, ok := r.prefixes[cacheKey]; ok {
+		return prefix, nil
+	}
+
+	tags, err := r.ReleaseTags(spec.Owner, spec.Repo)
+	if err != nil {
+		return "", err
+	}
+	prefix := ""
+	for _, candidate := range tagPrefixCandidates(spec.Path) {
+		for _, tag := range tags {
+			if tagPrefix, _ := splitTagPrefix(tag); strings.EqualFold(tagPrefix, candidate) {
+				prefix = tagPrefix
+				break
+			}
+		}
+		if prefix != "" {
+			break
+		}
+	}
+	r.prefixes[cacheKey] = prefix
+	return prefix, nil
+}
+
+// latestFamilyTag returns the newest non-prerelease tag in a tag family.
+func (r *TagResolver) latestFamilyTag(owner, repo, prefix string) (string, error) {
+	tags, err := r.ReleaseTags(owner, repo)
+	if err != nil {
+		return "", err
+	}
+	latest := ""
+	var latestVersion semver
+	for _, tag := range tags {
+		tagPrefix, _ := splitTagPrefix(tag)
+		if !strings.EqualFold(tagPrefix, prefix) {
+			continue
+		}
+		version, ok := parseTagVersion(tag)
+		if !ok || version.Prerelease != "" {
+			continue
+		}
+		if latest == "" || version.Compare(latestVersion) > 0 {
+			latest, latestVersion = tag, version
+		}
+	}
+	if latest == "" {
+		return "", fmt.Errorf("no release found with tag prefix %s for %s/%s", prefix, owner, repo)
+	}
+	return latest, nil
+}
+
+// determineFamilyVersion is determineVersion scoped to a tag family: the
+// repository's latest release may belong to another action, so the newest tag
+// with the prefix is used instead.
+func determineFamilyVersion(client restClient, resolver *TagResolver, owner, repo, prefix, override string) (string, string, error) {
+	if prefix == "" {
+		return determineVersion(client, resolver, owner, repo, override)
+	}
+	if override != "" {
+		return resolver.ResolveSpec(owner, repo, withTagPrefix(override, prefix))
+	}
+	tag, err := resolver.latestFamilyTag(owner, repo, prefix)
+	if err != nil {
+		return "", "", err
+	}
+	commit, err := resolver.Resolve(owner, repo, tag)
+	if err != nil {
+		return "", "", err
+	}
+	return tag, commit, nil
+}
+
+// splitTagFamilies divides a repository's usages by tag family, keeping the
+// order in which each family is first used.
+func splitTagFamilies(resolver *TagResolver, record *repoRecord, configured map[string]string) ([]*repoRecord, error) {
+	families := make(map[string]*repoRecord)
+	var order []*repoRecord
+	for _, usage := range record.Usages {
+		prefix, err := resolver.TagPrefix(usage.Spec, configured)
+		if err != nil {
+			return nil, err
+		}
+		family, ok := families[strings.ToLower(prefix)]
+		if !ok {
+			family = &repoRecord{Owner: record.Owner, Repo: record.Repo, Prefix: prefix}
+			families[strings.ToLower(prefix)] = family
+			order = append(order, family)
+		}
+		family.Usages = append(family.Usages, usage)
+	}
+	return order, nil
+}
@@ -0,0 +1,424 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSplitTagPrefix(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		tag     string
+		prefix  string
+		version string
+	}{
+		{"v1.2.0", "", "v1.2.0"},
+		{"setup-foo/v1.2.0", "setup-foo/", "v1.2.0"},
+		{"actions/setup-foo/v1", "actions/setup-foo/", "v1"},
+		{"foo@v2", "foo@", "v2"},
+		{"foo@2.1", "foo@", "2.1"},
+		{"release/latest", "", "release/latest"},
+		{"setup-foo/", "", "setup-foo/"},
+	}
+	for _, tc := range cases {
+		prefix, version := splitTagPrefix(tc.tag)
+		if prefix != tc.prefix || version != tc.version {
+			t.Fatalf("splitTagPrefix(%q) = %q, %q; want %q, %q", tc.tag, prefix, version, tc.prefix, tc.version)
+		}
+	}
+}
+
+func TestPrefixedVersionSpecs(t *testing.T) {
+	t.Parallel()
+
+	kind, normalized := classifyVersionSpec("setup-foo/V1.2")
+	if kind != specMinor || normalized != "setup-foo/v1.2" {
+		t.Fatalf("classifyVersionSpec = %v, %q; want minor setup-foo/v1.2", kind, normalized)
+	}
+
+	cases := []struct {
+		tag   string
+		spec  string
+		match bool
+	}{
+		{"setup-foo/v1.2.3", "setup-foo/v1", true},
+		{"setup-foo/v1.2.3", "setup-foo/v1.2", true},
+		{"setup-foo/v1.2.3", "v1", false},
+		{"v1.2.3", "setup-foo/v1", false},
+		{"other/v1.2.3", "setup-foo/v1", false},
+		{"foo@v2.0.1", "foo@v2", true},
+	}
+	for _, tc := range cases {
+		kind, normalized := classifyVersionSpec(tc.spec)
+		if got := matchVersionSpec(tc.tag, normalized, kind); got != tc.match {
+			t.Fatalf("matchVersionSpec(%q, %q) = %v, want %v", tc.tag, tc.spec, got, tc.match)
+		}
+	}
+}
+
+func TestTagPrefix(t *testing.T) {
+	t.Parallel()
+
+	mock := newMockRESTClient(t).
+		withJSON("repos/octo/tools/releases?per_page=100&page=1", []map[string]interface{}{
+			{"tag_name": "setup-foo/v1.3.0", "prerelease": false},
+			{"tag_name": "lint@v2.0.0", "prerelease": false},
+			{"tag_name": "v5.0.0", "prerelease": false},
+		})
+	resolver := NewTagResolver(mock)
+	configured := map[string]string{"octo/tools/build": "builder-"}
+
+	cases := []struct {
+		path   string
+		prefix string
+	}{
+		{"", ""},
+		{"setup-foo", "setup-foo/"},
+		{"actions/lint", "lint@"},
+		{"other", ""},
+		{"build", "builder-"},
+	}
+	for _, tc := range cases {
+		prefix, err := resolver.TagPrefix(ActionSpec{Owner: "octo", Repo: "tools", Path: tc.path}, configured)
+		if err != nil {
+			t.Fatalf("TagPrefix(%q) failed: %v", tc.path, err)
+		}
+		if prefix != tc.prefix {
+			t.Fatalf("TagPrefix(%q) = %q, want %q", tc.path, prefix, tc.prefix)
+		}
+	}
+	if calls := mock.callCounts["repos/octo/tools/releases?per_page=100&page=1"]; calls != 1 {
+		t.Fatalf("expected releases to be listed once, got %d", calls)
+	}
+}
+
+func TestRunUpdateMonorepo(t *testing.T) {
+	t.Parallel()
+	const initialCommit = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	const familyCommit = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+
+	mock := newMockRESTClient(t).
+		withJSON("repos/octo/tools/releases?per_page=100&page=1", []map[string]interface{}{
+			{"tag_name": "lint@v1.9.0", "prerelease": false},
+			{"tag_name": "setup-foo/v1.3.0", "prerelease": false},
+			{"tag_name": "v1.8.0", "prerelease": false},
+		}).
+		withJSON("repos/octo/tools/git/ref/tags/setup-foo/v1.3.0", map[string]interface{}{
+			"object": map[string]interface{}{"sha": familyCommit, "type": "commit"},
+		})
+
+	wf := buildWorkflowFile(t, `      - uses: octo/tools/setup-foo@`+initialCommit+` # v1`)
+	if exit := runUpdate(mock, []*WorkflowFile{wf}, []string{"octo/tools"}); exit != 0 {
+		t.Fatalf("runUpdate exit = %d, want 0", exit)
+	}
+	expected := `      - uses: octo/tools/setup-foo@` + familyCommit + ` # v1`
+	if wf.Lines[0] != expected {
+		t.Fatalf("line = %q, want %q", wf.Lines[0], expected)
+	}
+}
+
+func TestRunUpgradeMonorepo(t *testing.T) {
+	t.Parallel()
+	const initialCommit = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	const setupCommit = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	const lintCommit = "cccccccccccccccccccccccccccccccccccccccc"
+
+	mock := newMockRESTClient(t).
+		withJSON("repos/octo/tools/releases?per_page=100&page=1", []map[string]interface{}{
+			{"tag_name": "lint@v2.0.0", "prerelease": false},
+			{"tag_name": "setup-foo/v1.3.0", "prerelease": false},
+			{"tag_name": "setup-foo/v1.4.0-rc.1", "prerelease": true},
+			{"tag_name": "lint@v1.0.0", "prerelease": false},
+			{"tag_name": "setup-foo/v1.2.0", "prerelease": false},
+		}).
+		withJSON("repos/octo/tools/git/ref/tags/setup-foo/v1.3.0", map[string]interface{}{
+			"object": map[string]interface{}{"sha": setupCommit, "type": "commit"},
+		}).
+		withJSON("repos/octo/tools/git/ref/tags/lint@v2.0.0", map[string]interface{}{
+			"object": map[string]interface{}{"sha": lintCommit, "type": "commit"},
+		})
+	for _, ref := range []string{initialCommit, setupCommit} {
+		mock.withFile("octo/tools", "setup-foo/action.yml", ref, "runs:\n  using: node24\n")
+	}
+	for _, ref := range []string{initialCommit, lintCommit} {
+		mock.withFile("octo/tools", "lint/action.yml", ref, "runs:\n  using: node24\n")
+	}
+
+	wf := buildWorkflowFileFromLines(t,
+		`      - uses: octo/tools/setup-foo@`+initialCommit+` # setup-foo/v1.2.0`,
+		`      - uses: octo/tools/lint@`+initialCommit+` # v1.0.0`,
+	)
+	if exit := runUpgrade(mock, []*WorkflowFile{wf}, []string{"octo/tools"}); exit != 0 {
+		t.Fatalf("runUpgrade exit = %d, want 0", exit)
+	}
+	expected := []string{
+		`      - uses: octo/tools/setup-foo@` + setupCommit + ` # setup-foo/v1.3.0`,
+		`      - uses: octo/tools/lint@` + lintCommit + ` # lint@v2.0.0`,
+	}
+	for i, want := range expected {
+		if wf.Lines[i] != want {
+			t.Fatalf("line %d = %q, want %q", i+1, wf.Lines[i], want)
+		}
+	}
+}
+
+func TestRunFixAndVerifyMonorepo(t *testing.T) {
+	t.Parallel()
+	const olderCommit = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	const familyCommit = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+
+	mock := newMockRESTClient(t).
+		withJSON("repos/octo/tools/releases?per_page=100&page=1", []map[string]interface{}{
+			{"tag_name": "setup-foo/v1.3.0", "prerelease": false},
+			{"tag_name": "setup-foo/v1.2.0", "prerelease": false},
+		}).
+		withJSON("repos/octo/tools/git/ref/tags/setup-foo/v1.3.0", map[string]interface{}{
+			"object": map[string]interface{}{"sha": familyCommit, "type": "commit"},
+		}).
+		withJSON("repos/octo/tools/git/ref/tags/setup-foo/v1.2.0", map[string]interface{}{
+			"object": map[string]interface{}{"sha": olderCommit, "type": "commit"},
+		}).
+		withJSON("repos/octo/tools", map[string]interface{}{"full_name": "octo/tools", "default_branch": "main"}).
+		withJSON("repos/octo/tools/tags?per_page=100&page=1", []map[string]interface{}{
+			{"name": "setup-foo/v1.3.0", "commit": map[string]interface{}{"sha": familyCommit}},
+			{"name": "setup-foo/v1.2.0", "commit": map[string]interface{}{"sha": olderCommit}},
+		}).
+		withJSON("repos/octo/tools/compare/main..."+familyCommit, map[string]interface{}{"status": "identical"}).
+		withJSON("repos/octo/tools/compare/main..."+olderCommit, map[string]interface{}{"status": "behind", "behind_by": 2})
+
+	wf := buildWorkflowFileFromLines(t,
+		`      - uses: octo/tools/setup-foo@`+olderCommit+` # v1`,
+		`      - uses: octo/tools/setup-foo@`+olderCommit+` # v1.2.0`,
+	)
+	if exit := runVerify(mock, []*WorkflowFile{wf}); exit == 0 {
+		t.Fatal("expected runVerify to report a commit behind the v1 family")
+	}
+	if exit := runFix(mock, []*WorkflowFile{wf}, nil); exit != 0 {
+		t.Fatalf("runFix exit = %d, want 0", exit)
+	}
+	expected := []string{
+		`      - uses: octo/tools/setup-foo@` + familyCommit + ` # v1`,
+		`      - uses: octo/tools/setup-foo@` + olderCommit + ` # v1.2.0`,
+	}
+	for i, want := range expected {
+		if wf.Lines[i] != want {
+			t.Fatalf("line %d = %q, want %q", i+1, wf.Lines[i], want)
+		}
+	}
+	if exit := runVerify(mock, []*WorkflowFile{wf}); exit != 0 {
+		t.Fatalf("runVerify exit = %d, want 0", exit)
+	}
+}
+
+func TestRunOutdatedMonorepo(t *testing.T) {
+	t.Parallel()
+	const pinnedCommit = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	const wantedCommit = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	const latestCommit = "cccccccccccccccccccccccccccccccccccccccc"
+
+	mock := newMockRESTClient(t).
+		withJSON("repos/octo/tools/releases?per_page=100&page=1", []map[string]interface{}{
+			{"tag_name": "lint@v9.0.0", "prerelease": false},
+			{"tag_name": "setup-foo/v2.0.0", "prerelease": false},
+			{"tag_name": "setup-foo/v1.3.0", "prerelease": false},
+			{"tag_name": "setup-foo/v1.2.0", "prerelease": false},
+		}).
+		withJSON("repos/octo/tools/git/ref/tags/setup-foo/v1.3.0", map[string]interface{}{
+			"object": map[string]interface{}{"sha": wantedCommit, "type": "commit"},
+		}).
+		withJSON("repos/octo/tools/git/ref/tags/setup-foo/v2.0.0", map[string]interface{}{
+			"object": map[string]interface{}{"sha": latestCommit, "type": "commit"},
+		}).
+		withJSON("repos/octo/tools/tags?per_page=100&page=1", []map[string]interface{}{
+			{"name": "setup-foo/v1.2.0", "commit": map[string]interface{}{"sha": pinnedCommit}},
+		})
+
+	wf := buildWorkflowFile(t, `      - uses: octo/tools/setup-foo@`+pinnedCommit+` # v1`)
+
+	var out bytes.Buffer
+	if exit := runOutdated(&out, mock, []*WorkflowFile{wf}, []string{"--format", "json"}); exit != 0 {
+		t.Fatalf("runOutdated exit = %d, want 0", exit)
+	}
+	var entries []outdatedEntry
+	if err := json.Unmarshal(out.Bytes(), &entries); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	entry := entries[0]
+	if entry.Action != "octo/tools/setup-foo" || entry.Current != "setup-foo/v1.2.0" ||
+		entry.Wanted != "setup-foo/v1.3.0" || entry.Latest != "setup-foo/v2.0.0" {
+		t.Fatalf("unexpected entry: %+v", entry)
+	}
+	if !entry.MajorBump || !entry.Outdated {
+		t.Fatalf("expected an outdated major bump: %+v", entry)
+	}
+}
+
+func TestRunChangesMonorepo(t *testing.T) {
+	t.Parallel()
+	const pinnedCommit = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	const familyCommit = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+
+	mock := newMockRESTClient(t).
+		withJSON("repos/octo/tools/releases?per_page=100&page=1", []map[string]interface{}{
+			{"tag_name": "lint@v1.9.0", "prerelease": false},
+			{"tag_name": "setup-foo/v1.3.0", "prerelease": false},
+			{"tag_name": "setup-foo/v1.2.0", "prerelease": false},
+		}).
+		withJSON("repos/octo/tools/git/ref/tags/setup-foo/v1.3.0", map[string]interface{}{
+			"object": map[string]interface{}{"sha": familyCommit, "type": "commit"},
+		}).
+		withFile("octo/tools", "setup-foo/action.yml", pinnedCommit, "runs:\n  using: node20\n").
+		withFile("octo/tools", "setup-foo/action.yml", familyCommit, "inputs:\n  token:\n    required: false\nruns:\n  using: node24\n")
+
+	wf := buildWorkflowFile(t, `      - uses: octo/tools/setup-foo@`+pinnedCommit+` # v1.2.0`)
+
+	var out bytes.Buffer
+	if exit := runChanges(&out, mock, []*WorkflowFile{wf}, []string{"octo/tools"}); exit != 0 {
+		t.Fatalf("runChanges exit = %d, want 0", exit)
+	}
+	report := out.String()
+	for _, want := range []string{"setup-foo/v1.3.0", "runs.using changed: node20 -> node24", "input `token` added"} {
+		if !strings.Contains(report, want) {
+			t.Fatalf("report missing %q:\n%s", want, report)
+		}
+	}
+	if strings.Contains(report, "lint@") {
+		t.Fatalf("report compared against another action's release:\n%s", report)
+	}
+}
+
+func TestRunFixExactMonorepo(t *testing.T) {
+	t.Parallel()
+	const olderCommit = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	const familyCommit = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+
+	mock := newMockRESTClient(t).
+		withJSON("repos/octo/tools/releases?per_page=100&page=1", []map[string]interface{}{
+			{"tag_name": "setup-foo/v1.3.0", "prerelease": false},
+		}).
+		withJSON("repos/octo/tools/git/ref/tags/setup-foo/v1.3.0", map[string]interface{}{
+			"object": map[string]interface{}{"sha": familyCommit, "type": "commit"},
+		}).
+		withJSON("repos/octo/tools", map[string]interface{}{"full_name": "octo/tools", "default_branch": "main"}).
+		withJSON("repos/octo/tools/tags?per_page=100&page=1", []map[string]interface{}{
+			{"name": "setup-foo/v1.3.0", "commit": map[string]interface{}{"sha": familyCommit}},
+		}).
+		withJSON("repos/octo/tools/compare/main..."+familyCommit, map[string]interface{}{"status": "identical"})
+
+	wf := buildWorkflowFileFromLines(t,
+		`      - uses: octo/tools/setup-foo@`+olderCommit+` # v1`,
+		`      - uses: octo/tools/setup-foo@`+olderCommit+` # v1.3.0`,
+		`      - uses: octo/tools/setup-foo@`+olderCommit+` # setup-foo/v1`,
+	)
+	if exit := runFix(mock, []*WorkflowFile{wf}, []string{"--exact"}); exit != 0 {
+		t.Fatalf("runFix exit = %d, want 0", exit)
+	}
+	expected := []string{
+		`      - uses: octo/tools/setup-foo@` + familyCommit + ` # v1 (v1.3.0)`,
+		`      - uses: octo/tools/setup-foo@` + familyCommit + ` # v1.3.0`,
+		`      - uses: octo/tools/setup-foo@` + familyCommit + ` # setup-foo/v1 (setup-foo/v1.3.0)`,
+	}
+	for i, want := range expected {
+		if wf.Lines[i] != want {
+			t.Fatalf("line %d = %q, want %q", i+1, wf.Lines[i], want)
+		}
+	}
+	if exit := runVerify(mock, []*WorkflowFile{wf}); exit != 0 {
+		t.Fatalf("runVerify exit = %d, want 0", exit)
+	}
+}
+
+func TestRunRuntimesMonorepo(t *testing.T) {
+	t.Parallel()
+	const pinnedCommit = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	const fixedCommit = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+
+	// Other families' releases are never read.
+	mock := newMockRESTClient(t).
+		withFile("octo/tools", "setup-foo/action.yml", pinnedCommit, "runs:\n  using: node16\n").
+		withFile("octo/tools", "setup-foo/action.yml", url.QueryEscape("setup-foo/v1.1.0"), "runs:\n  using: node16\n").
+		withFile("octo/tools", "setup-foo/action.yml", url.QueryEscape("setup-foo/v1.2.0"), "runs:\n  using: node24\n").
+		withFile("octo/tools", "setup-foo/action.yml", url.QueryEscape("setup-foo/v2.0.0"), "runs:\n  using: node24\n").
+		withJSON("repos/octo/tools/releases?per_page=100&page=1", []map[string]interface{}{
+			{"tag_name": "lint@v1.1.5", "prerelease": false},
+			{"tag_name": "setup-foo/v2.0.0", "prerelease": false},
+			{"tag_name": "setup-foo/v1.2.0", "prerelease": false},
+			{"tag_name": "setup-foo/v1.1.0", "prerelease": false},
+			{"tag_name": "setup-foo/v1.0.0", "prerelease": false},
+		}).
+		withJSON("repos/octo/tools/git/ref/tags/setup-foo/v1.2.0", map[string]interface{}{
+			"object": map[string]interface{}{"sha": fixedCommit, "type": "commit"},
+		})
+
+	wf := buildWorkflowFile(t, "      - uses: octo/tools/setup-foo@"+pinnedCommit+" # v1.0.0")
+
+	var out bytes.Buffer
+	if exit := runRuntimes(&out, mock, []*WorkflowFile{wf}, []string{"--format", "json"}); exit != 0 {
+		t.Fatalf("runRuntimes exit = %d, want 0", exit)
+	}
+	var entries []runtimeEntry
+	if err := json.Unmarshal(out.Bytes(), &entries); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out.String())
+	}
+	if len(entries) != 1 || entries[0].Suggested != "setup-foo/v1.2.0" || entries[0].SuggestedCommit != fixedCommit {
+		t.Fatalf("expected setup-foo/v1.2.0 to be suggested, got %+v", entries)
+	}
+}
+
+func TestRunAuditMonorepo(t *testing.T) {
+	t.Parallel()
+	const vulnerableCommit = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	const patchedCommit = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+
+	advisoryPath := filepath.Join(t.TempDir(), "advisories.json")
+	advisories := `[{"ghsaId": "GHSA-xxxx-yyyy-zzzz", "summary": "setup-foo runs untrusted input", "severity": "HIGH",
+		"package": "octo/tools", "vulnerableVersionRange": "< 1.2.0", "firstPatchedVersion": "1.2.0"}]`
+	if err := os.WriteFile(advisoryPath, []byte(advisories), 0o644); err != nil {
+		t.Fatalf("failed to write advisories: %v", err)
+	}
+
+	mock := newMockRESTClient(t).
+		withJSON("repos/octo/tools/releases?per_page=100&page=1", []map[string]interface{}{
+			{"tag_name": "lint@v9.0.0", "prerelease": false},
+			{"tag_name": "setup-foo/v1.2.0", "prerelease": false},
+			{"tag_name": "setup-foo/v1.1.0", "prerelease": false},
+		}).
+		withJSON("repos/octo/tools/tags?per_page=100&page=1", []map[string]interface{}{
+			{"name": "setup-foo/v1.2.0", "commit": map[string]interface{}{"sha": patchedCommit}},
+			{"name": "setup-foo/v1.1.0", "commit": map[string]interface{}{"sha": vulnerableCommit}},
+		}).
+		withJSON("repos/octo/tools/git/ref/tags/setup-foo/v1.2.0", map[string]interface{}{
+			"object": map[string]interface{}{"sha": patchedCommit, "type": "commit"},
+		})
+
+	wf := buildWorkflowFile(t, "      - uses: octo/tools/setup-foo@"+vulnerableCommit+" # v1")
+
+	var out bytes.Buffer
+	if exit := runAudit(&out, mock, nil, []*WorkflowFile{wf}, []string{"--advisories", advisoryPath, "--format", "json"}); exit != 1 {
+		t.Fatalf("expected audit to report the vulnerable usage, got %d:\n%s", exit, out.String())
+	}
+	var findings []auditFinding
+	if err := json.Unmarshal(out.Bytes(), &findings); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	if len(findings) != 1 || findings[0].Version != "setup-foo/v1.1.0" {
+		t.Fatalf("expected the finding to name setup-foo/v1.1.0, got %+v", findings)
+	}
+
+	out.Reset()
+	if exit := runAudit(&out, mock, nil, []*WorkflowFile{wf}, []string{"--advisories", advisoryPath, "--fix"}); exit != 0 {
+		t.Fatalf("expected audit --fix to resolve the finding, got %d:\n%s", exit, out.String())
+	}
+	if want := "      - uses: octo/tools/setup-foo@" + patchedCommit + " # setup-foo/v1.2.0"; wf.Lines[0] != want {
+		t.Fatalf("fixed line = %q, want %q", wf.Lines[0], want)
+	}
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+)
+
+type whyUsage struct {
+	Location string    `json:"location"`
+	Action   string    `json:"action"`
+	Kind     usageKind `json:"kind"`
+	Ref      string    `json:"ref"`
+	Version  string    `json:"version,omitempty"`
+}
+
+// whyChain is an indirect use: Via lists the ./ references and remote
+// reusable workflow calls, outermost first, that lead from a workflow to the
+// file containing Usage.
+type whyChain struct {
+	Via   []string `json:"via"`
+	Usage whyUsage `json:"usage"`
+}
+
+type whyReport struct {
+	Action   string     `json:"action"`
+	Direct   []whyUsage `json:"direct"`
+	Indirect []whyChain `json:"indirect"`
+}
+
+func cmdWhy(args []string) int {
+	files, err := loadWorkflowFiles()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load workflow files: %v\n", err)
+		return 1
+	}
+
+	// The client is only needed to read remote reusable workflows; without
+	// one, only local chains are followed.
+	var client restClient
+	if rest, err := api.DefaultRESTClient(); err == nil {
+		client = rest
+	}
+
+	return runWhy(os.Stdout, client, files, args)
+}
+
+func runWhy(w io.Writer, client restClient, files []*WorkflowFile, args []string) int {
+	fs := flag.NewFlagSet("why", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	format := fs.String("format", "table", "output format (table, json)")
+
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "why requires exactly one owner/repo argument")
+		return 1
+	}
+
+	target := fs.Arg(0)
+	if at := strings.Index(target, "@"); at >= 0 {
+		target = target[:at]
+	}
+	if parts := strings.Split(target, "/"); len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		fmt.Fprintf(os.Stderr, "invalid action %q (expected owner/repo)\n", fs.Arg(0))
+		return 1
+	}
+
+	if *format != "table" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "unknown format %q (expected table or json)\n", *format)
+		return 1
+	}
+
+	var workflows *reusableWorkflowChecker
+	if client != nil {
+		workflows = newReusableWorkflowChecker(client, Policy{})
+	}
+	report, warnings := buildWhyReport(files, target, workflows)
+	for _, warning := range warnings {
+		fmt.Fprintln(os.Stderr, warning)
+	}
+
+	if *format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write report: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	writeWhyReport(w, report)
+	return 0
+}
+
+// matchesWhyTarget reports whether a usage refers to target: any path in the
+// repository for owner/repo, or exactly the given path for owner/repo/path.
+func matchesWhyTarget(spec ActionSpec, target string) bool {
+	if strings.Count(target, "/") > 1 {
+		return strings.EqualFold(spec.FullPath(), target)
+	}
+	return spec.RepoKey() == strings.ToLower(target)
+}
+
+// buildWhyReport lists the direct usages of target and, for each one, the
+// chains of local composite action and reusable workflow references through
+// which other files reach it. With a checker, remote reusable workflows are
+// read at their pinned refs and uses of target inside them are reported as
+// indirect too; workflows that cannot be read are returned as warnings.
+func buildWhyReport(files []*WorkflowFile, target string, workflows *reusableWorkflowChecker) (*whyReport, []string) {
+	report := &whyReport{Action: target, Direct: []whyUsage{}, Indirect: []whyChain{}}
+	var warnings []string
+
+	callers := make(map[*WorkflowFile][]*LocalUsage)
+	for _, file := range files {
+		for _, local := range file.Local {
+			if resolved := resolveLocalTarget(files, local.Target()); resolved != nil {
+				callers[resolved] = append(callers[resolved], local)
+			}
+		}
+	}
+
+	// addIndirect records usage once for every chain of ./ references that
+	// reaches file, followed by the given remote calls.
+	addIndirect := func(file *WorkflowFile, remote []string, usage whyUsage) {
+		for _, chain := range localChains(callers, file, map[*WorkflowFile]bool{file: true}) {
+			if len(chain) == 0 && len(remote) == 0 {
+				continue
+			}
+			via := make([]string, 0, len(chain)+len(remote))
+			for _, local := range chain {
+				via = append(via, fmt.Sprintf("%s:%d (%s)", local.File.Path, local.LineNumber(), local.Path))
+			}
+			report.Indirect = append(report.Indirect, whyChain{Via: append(via, remote...), Usage: usage})
+		}
+	}
+
+	for _, usage := range allUsages(files) {
+		if matchesWhyTarget(usage.Spec, target) {
+			direct := newWhyUsage(usage, fmt.Sprintf("%s:%d", usage.File.Path, usage.LineNumber()))
+			report.Direct = append(report.Direct, direct)
+			addIndirect(usage.File, nil, direct)
+		}
+
+		if workflows == nil || usage.Kind() != usageKindReusableWorkflow {
+			continue
+		}
+		called, err := workflows.fetch(usage.Spec, usage.Ref)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s:%d unable to read %s: %v",
+				usage.File.Path, usage.LineNumber(), reusableCallName(usage), err))
+			continue
+		}
+		visited := map[string]bool{strings.ToLower(reusableCallName(usage)): true}
+		workflows.walk(called, []*ActionUsage{usage}, visited,
+			func(calls []*ActionUsage, nested *ActionUsage) {
+				if !matchesWhyTarget(nested.Spec, target) {
+					return
+				}
+				// Lines inside a remote workflow are located by its path@ref.
+				location := fmt.Sprintf("%s:%d", usage.File.Path, usage.LineNumber())
+				remote := make([]string, len(calls))
+				for i, call := range calls {
+					if i > 0 {
+						location = fmt.Sprintf("%s:%d", reusableCallName(calls[i-1]), call.LineNumber())
+					}
+					remote[i] = fmt.Sprintf("%s (%s)", location, reusableCallName(call))
+				}
+				location = fmt.Sprintf("%s:%d", reusableCallName(calls[len(calls)-1]), nested.LineNumber())
+				addIndirect(usage.File, remote, newWhyUsage(nested, location))
+			},
+			func(calls []*ActionUsage, nested *ActionUsage, err error) {
+				warnings = append(warnings, fmt.Sprintf("%s calls %s, which could not be read: %v",
+					reusableVia(calls), reusableCallName(nested), err))
+			})
+	}
+	return report, warnings
+}
+
+func newWhyUsage(usage *ActionUsage, location string) whyUsage {
+	version, _ := splitComment(usage.Comment)
+	return whyUsage{
+		Location: location,
+		Action:   usage.Spec.FullPath(),
+		Kind:     usage.Kind(),
+		Ref:      usage.Ref,
+		Version:  version,
+	}
+}
+
+// localChains returns every path of ./ references, outermost first, that
+// ends at file. A file nothing references yields a single empty path.
+// Files already on the current path are skipped to avoid cycles.
+func localChains(callers map[*WorkflowFile][]*LocalUsage, file *WorkflowFile, seen map[*WorkflowFile]bool) [][]*LocalUsage {
+	var chains [][]*LocalUsage
+	for _, caller := range callers[file] {
+		if seen[caller.File] {
+			continue
+		}
+		seen[caller.File] = true
+		for _, chain := range localChains(callers, caller.File, seen) {
+			chains = append(chains, append(chain, caller))
+		}
+		delete(seen, caller.File)
+	}
+	if len(chains) == 0 {
+		return [][]*LocalUsage{nil}
+	}
+	return chains
+}
+
+func writeWhyReport(w io.Writer, report *whyReport) {
+	if len(report.Direct) == 0 && len(report.Indirect) == 0 {
+		fmt.Fprintf(w, "%s is not used by any workflow or composite action.\n", report.Action)
+		return
+	}
+
+	if len(report.Direct) > 0 {
+		fmt.Fprintf(w, "%s is used directly at:\n", report.Action)
+		for _, usage := range report.Direct {
+			fmt.Fprintf(w, "  %s  %s\n", usage.Location, whyUsageLabel(usage))
+		}
+	}
+
+	if len(report.Indirect) == 0 {
+		return
+	}
+	if len(report.Direct) > 0 {
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintln(w, "Used indirectly through local actions and reusable workflows:")
+	for _, chain := range report.Indirect {
+		fmt.Fprintf(w, "  %s -> %s  %s\n", strings.Join(chain.Via, " -> "), chain.Usage.Location, whyUsageLabel(chain.Usage))
+	}
+}
+
+func whyUsageLabel(usage whyUsage) string {
+	label := fmt.Sprintf("%s@%s", usage.Action, refLabel(usage.Ref))
+	if usage.Kind == usageKindReusableWorkflow {
+		label = "reusable workflow " + label
+	}
+	if usage.Version != "" {
+		label += fmt.Sprintf(" (%s)", usage.Version)
+	}
+	return label
+}
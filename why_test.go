@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRunWhy(t *testing.T) {
+	t.Parallel()
+
+	files := append(graphTestFiles(),
+		newWorkflowFile(".github/workflows/release.yml", []byte(strings.Join([]string{
+			"jobs:",
+			"  build:",
+			"    uses: ./.github/workflows/reusable.yml",
+		}, "\n")+"\n")),
+	)
+
+	var out bytes.Buffer
+	if exit := runWhy(&out, nil, files, []string{"actions/setup-node"}); exit != 0 {
+		t.Fatalf("expected success, got %d", exit)
+	}
+	expected := strings.Join([]string{
+		"actions/setup-node is used directly at:",
+		"  .github/actions/setup/action.yml:4  actions/setup-node@v4",
+		"",
+		"Used indirectly through local actions and reusable workflows:",
+		"  .github/workflows/ci.yml:5 (./.github/actions/setup) -> .github/actions/setup/action.yml:4  actions/setup-node@v4",
+		"",
+	}, "\n")
+	if out.String() != expected {
+		t.Fatalf("unexpected output:\n%s\nwant:\n%s", out.String(), expected)
+	}
+
+	out.Reset()
+	if exit := runWhy(&out, nil, files, []string{"--format", "json", "Actions/Checkout"}); exit != 0 {
+		t.Fatalf("expected success, got %d", exit)
+	}
+	var report whyReport
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out.String())
+	}
+	if len(report.Direct) != 2 {
+		t.Fatalf("expected two direct uses of checkout, got %+v", report.Direct)
+	}
+	if report.Direct[0].Version != "v4.1.0" {
+		t.Fatalf("expected the version comment, got %+v", report.Direct[0])
+	}
+	var chains []string
+	for _, chain := range report.Indirect {
+		chains = append(chains, strings.Join(chain.Via, " -> "))
+	}
+	if len(chains) != 2 ||
+		chains[0] != ".github/workflows/ci.yml:8 (./.github/workflows/reusable.yml)" ||
+		chains[1] != ".github/workflows/release.yml:3 (./.github/workflows/reusable.yml)" {
+		t.Fatalf("unexpected chains: %q", chains)
+	}
+
+	out.Reset()
+	if exit := runWhy(&out, nil, files, []string{"octo/unused"}); exit != 0 {
+		t.Fatalf("expected success, got %d", exit)
+	}
+	if !strings.Contains(out.String(), "octo/unused is not used") {
+		t.Fatalf("unexpected output: %s", out.String())
+	}
+
+	if exit := runWhy(&bytes.Buffer{}, nil, files, []string{"checkout"}); exit != 1 {
+		t.Fatalf("expected an invalid argument to fail, got %d", exit)
+	}
+}
+
+func TestRunWhyRemoteReusableWorkflow(t *testing.T) {
+	t.Parallel()
+	const buildCommit = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	const lintCommit = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	const missingCommit = "cccccccccccccccccccccccccccccccccccccccc"
+
+	mock := newMockRESTClient(t).
+		withFile("octo/pipelines", ".github/workflows/build.yml", buildCommit, strings.Join([]string{
+			"on: workflow_call",
+			"jobs:",
+			"  build:",
+			"    steps:",
+			"      - uses: actions/setup-node@v4",
+			"  lint:",
+			"    uses: octo/pipelines/.github/workflows/lint.yml@" + lintCommit,
+		}, "\n")+"\n").
+		withFile("octo/pipelines", ".github/workflows/lint.yml", lintCommit, strings.Join([]string{
+			"on: workflow_call",
+			"jobs:",
+			"  lint:",
+			"    steps:",
+			"      - uses: actions/setup-node@v3 # v3.8.0",
+		}, "\n")+"\n").
+		withError("repos/octo/pipelines/contents/.github/workflows/gone.yml?ref="+missingCommit, errors.New("not found"))
+
+	files := []*WorkflowFile{
+		newWorkflowFile(".github/workflows/deploy.yml", []byte(strings.Join([]string{
+			"jobs:",
+			"  deploy:",
+			"    uses: ./.github/workflows/shared.yml",
+		}, "\n")+"\n")),
+		newWorkflowFile(".github/workflows/shared.yml", []byte(strings.Join([]string{
+			"on: workflow_call",
+			"jobs:",
+			"  build:",
+			"    uses: octo/pipelines/.github/workflows/build.yml@" + buildCommit,
+			"  gone:",
+			"    uses: octo/pipelines/.github/workflows/gone.yml@" + missingCommit,
+		}, "\n")+"\n")),
+	}
+
+	report, warnings := buildWhyReport(files, "actions/setup-node", newReusableWorkflowChecker(mock, Policy{}))
+	if len(report.Direct) != 0 {
+		t.Fatalf("expected no direct uses, got %+v", report.Direct)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "gone.yml") {
+		t.Fatalf("expected a warning for the unreadable workflow, got %q", warnings)
+	}
+
+	var out bytes.Buffer
+	writeWhyReport(&out, report)
+	local := ".github/workflows/deploy.yml:3 (./.github/workflows/shared.yml) -> "
+	build := ".github/workflows/shared.yml:4 (octo/pipelines/.github/workflows/build.yml@aaaaaaaaaaaa) -> "
+	expected := strings.Join([]string{
+		"Used indirectly through local actions and reusable workflows:",
+		"  " + local + build + "octo/pipelines/.github/workflows/build.yml@aaaaaaaaaaaa:5  actions/setup-node@v4",
+		"  " + local + build + "octo/pipelines/.github/workflows/build.yml@aaaaaaaaaaaa:7 (octo/pipelines/.github/workflows/lint.yml@bbbbbbbbbbbb) -> " +
+			"octo/pipelines/.github/workflows/lint.yml@bbbbbbbbbbbb:5  actions/setup-node@v3 (v3.8.0)",
+		"",
+	}, "\n")
+	if out.String() != expected {
+		t.Fatalf("unexpected output:\n%s\nwant:\n%s", out.String(), expected)
+	}
+}